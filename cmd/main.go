@@ -16,7 +16,9 @@ import (
 	"wallet/pkg/common/config"
 	"wallet/pkg/hdwallet/eth"
 	"wallet/pkg/hdwallet/tron"
+	"wallet/pkg/kms"
 	"wallet/pkg/network"
+	"wallet/pkg/signer"
 	"wallet/pkg/zlogger"
 )
 
@@ -36,6 +38,12 @@ func main() {
 	// Init DB
 	mysqlConfig.InitDB()
 
+	// Init key-encryption key
+	kms.Init()
+
+	// Connect remote signer
+	signer.Init()
+
 	engine := gin.Default()
 	router.InitRouter(engine)
 
@@ -0,0 +1,54 @@
+// Command rewrap moves the data keys of all stored wallets to the KEK in the
+// current config. Point the -from-* flags at the previous KEK.
+package main
+
+import (
+	"context"
+	"flag"
+	mysqlConfig "wallet/config"
+	"wallet/internal/service"
+	"wallet/pkg/common/config"
+	"wallet/pkg/kms"
+	"wallet/pkg/zlogger"
+)
+
+func main() {
+	var fromProvider, fromKeyFile, fromEnvVar, fromKeyLabel string
+	flag.StringVar(&fromProvider, "from-provider", "", "provider of the previous kek: file, env or pkcs11")
+	flag.StringVar(&fromKeyFile, "from-key-file", "", "key file of the previous kek")
+	flag.StringVar(&fromEnvVar, "from-env-var", "", "environment variable of the previous kek")
+	flag.StringVar(&fromKeyLabel, "from-pkcs11-key-label", "", "pkcs11 key label of the previous kek, module and token come from config")
+
+	configFile, logFile, _, _, _, err := config.FlagParse("rewrap")
+	if err != nil {
+		panic(err)
+	}
+	err = config.InitConfig(configFile)
+	if err != nil {
+		panic(err)
+	}
+
+	zlogger.InitLogConfig(logFile)
+	mysqlConfig.InitDB()
+	kms.Init()
+
+	if fromProvider == "" {
+		panic("-from-provider is required")
+	}
+	fromConfig := config.Config.Kms
+	fromConfig.Provider = fromProvider
+	fromConfig.KeyFile = fromKeyFile
+	fromConfig.EnvVar = fromEnvVar
+	fromConfig.Pkcs11.KeyLabel = fromKeyLabel
+	from, err := kms.NewProvider(fromConfig)
+	if err != nil {
+		panic(err)
+	}
+
+	count, err := service.RewrapWallets(context.Background(), from)
+	if err != nil {
+		zlogger.Errorf("rewrap stopped after %d wallets: %v", count, err)
+		panic(err)
+	}
+	zlogger.Infof("rewrapped %d wallets", count)
+}
@@ -0,0 +1,88 @@
+// Command signer is a minimal signing host for the remote signer backend. It
+// serves every keystore v3 file of -keystore-dir under its file name without
+// extension, all unlocked with the password in -password-env.
+//
+// Clients must connect over TLS and present a certificate issued by
+// -client-ca, a token from -tokens-file, or both. -insecure-dev drops all of
+// that for local development.
+package main
+
+import (
+	"flag"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"google.golang.org/grpc"
+	"wallet/pkg/signer"
+	"wallet/pkg/zlogger"
+)
+
+func main() {
+	var listen, keystoreDir, passwordEnv, certFile, keyFile, clientCAFile, tokensFile, logFile string
+	var insecureDev bool
+	flag.StringVar(&listen, "listen", "127.0.0.1:10010", "address to serve the signer on")
+	flag.StringVar(&keystoreDir, "keystore-dir", "./keystore", "directory of keystore v3 files")
+	flag.StringVar(&passwordEnv, "password-env", "SIGNER_KEYSTORE_PASSWORD", "environment variable holding the keystore password")
+	flag.StringVar(&certFile, "tls-cert", "", "tls certificate")
+	flag.StringVar(&keyFile, "tls-key", "", "tls private key")
+	flag.StringVar(&clientCAFile, "client-ca", "", "CA certificate that client certificates must be issued by")
+	flag.StringVar(&tokensFile, "tokens-file", "", "file of \"<client> <token>\" lines accepted as bearer tokens")
+	flag.BoolVar(&insecureDev, "insecure-dev", false, "serve plaintext without client authentication, development only")
+	flag.StringVar(&logFile, "log_file", "lumberjack://./logs/signer.log", "log file name")
+	flag.Parse()
+
+	zlogger.InitLogConfig(logFile)
+
+	files, err := filepath.Glob(filepath.Join(keystoreDir, "*.json"))
+	if err != nil {
+		panic(err)
+	}
+	signers := make(map[string]signer.Signer, len(files))
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			panic(err)
+		}
+		s, err := signer.NewKeystoreSigner(data, os.Getenv(passwordEnv))
+		if err != nil {
+			zlogger.Errorf("unlock keystore %s error %v", file, err)
+			panic(err)
+		}
+		signers[strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))] = s
+	}
+
+	var opts []grpc.ServerOption
+	if insecureDev {
+		zlogger.Warnf("signer serving without tls or client authentication, development only")
+	} else {
+		if certFile == "" || (clientCAFile == "" && tokensFile == "") {
+			zlogger.Errorf("signer needs -tls-cert, -tls-key and -client-ca or -tokens-file, or -insecure-dev")
+			os.Exit(2)
+		}
+		creds, err := signer.ServerCredentials(certFile, keyFile, clientCAFile)
+		if err != nil {
+			panic(err)
+		}
+		opts = append(opts, grpc.Creds(creds))
+		if tokensFile != "" {
+			tokens, err := signer.LoadTokens(tokensFile)
+			if err != nil {
+				panic(err)
+			}
+			opts = append(opts, grpc.UnaryInterceptor(signer.TokenAuth(tokens)))
+		}
+	}
+	server := grpc.NewServer(opts...)
+	signer.NewRemoteServer(signers).Register(server)
+
+	lis, err := net.Listen("tcp", listen)
+	if err != nil {
+		panic(err)
+	}
+	zlogger.Infof("signer serving %d keys on %s", len(signers), listen)
+	if err = server.Serve(lis); err != nil {
+		panic(err)
+	}
+}
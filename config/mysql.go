@@ -3,6 +3,9 @@ package config
 import (
 	"gorm.io/gorm"
 	"wallet/pkg/db/dbconn"
+	"wallet/pkg/db/model/deposit"
+	"wallet/pkg/db/model/multisig"
+	"wallet/pkg/db/model/wallet"
 	"wallet/pkg/zlogger"
 )
 
@@ -17,4 +20,9 @@ func InitDB() {
 
 	DB = db
 	zlogger.Info("Connected to database successfully")
+
+	if err = DB.AutoMigrate(&wallet.WalletInfo{}, &deposit.AddressIndex{}, &deposit.AddressInfo{}, &multisig.Transaction{}, &multisig.SafeTransaction{}); err != nil {
+		zlogger.Errorf("Error migrating database: %s", err.Error())
+		panic(err)
+	}
 }
@@ -0,0 +1,196 @@
+package controller
+
+import (
+	"crypto/subtle"
+
+	"github.com/gin-gonic/gin"
+	"wallet/internal/apiResponse"
+	"wallet/internal/message"
+	"wallet/internal/requests"
+	"wallet/internal/service"
+	"wallet/pkg/common/config"
+)
+
+const adminTokenHeader = "X-Admin-Token"
+
+// AdminAuth rejects requests without the configured admin token, the admin
+// endpoints stay closed while no token is configured.
+func AdminAuth(c *gin.Context) {
+	token := config.Config.Admin.Token
+	if token == "" || subtle.ConstantTimeCompare([]byte(c.GetHeader(adminTokenHeader)), []byte(token)) != 1 {
+		apiResponse.Fail(message.Unauthorized).Json(c)
+		c.Abort()
+		return
+	}
+	c.Next()
+}
+
+func SplitMnemonic(c *gin.Context) {
+	var req requests.SplitMnemonicRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apiResponse.Fail(message.ParamError).Json(c)
+		return
+	}
+	service.SplitMnemonic(c, req).Json(c)
+	return
+}
+
+func CombineShares(c *gin.Context) {
+	var req requests.CombineSharesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apiResponse.Fail(message.ParamError).Json(c)
+		return
+	}
+	service.CombineShares(c, req).Json(c)
+	return
+}
+
+func FreezeBalance(c *gin.Context) {
+	var req requests.StakeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apiResponse.Fail(message.ParamError).Json(c)
+		return
+	}
+	service.FreezeBalance(c, req).Json(c)
+	return
+}
+
+func UnfreezeBalance(c *gin.Context) {
+	var req requests.StakeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apiResponse.Fail(message.ParamError).Json(c)
+		return
+	}
+	service.UnfreezeBalance(c, req).Json(c)
+	return
+}
+
+func WithdrawExpireUnfreeze(c *gin.Context) {
+	var req requests.WithdrawUnfreezeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apiResponse.Fail(message.ParamError).Json(c)
+		return
+	}
+	service.WithdrawExpireUnfreeze(c, req).Json(c)
+	return
+}
+
+func DelegateResource(c *gin.Context) {
+	var req requests.DelegateResourceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apiResponse.Fail(message.ParamError).Json(c)
+		return
+	}
+	service.DelegateResource(c, req).Json(c)
+	return
+}
+
+func UnDelegateResource(c *gin.Context) {
+	var req requests.DelegateResourceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apiResponse.Fail(message.ParamError).Json(c)
+		return
+	}
+	service.UnDelegateResource(c, req).Json(c)
+	return
+}
+
+func CreateMultisigTransfer(c *gin.Context) {
+	var req requests.CreateMultisigTransferRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apiResponse.Fail(message.ParamError).Json(c)
+		return
+	}
+	service.CreateMultisigTransfer(c, req).Json(c)
+	return
+}
+
+func UpdateAccountPermission(c *gin.Context) {
+	var req requests.UpdatePermissionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apiResponse.Fail(message.ParamError).Json(c)
+		return
+	}
+	service.UpdateAccountPermission(c, req).Json(c)
+	return
+}
+
+func GetMultisigTransaction(c *gin.Context) {
+	service.GetMultisigTransaction(c, c.Param("txId")).Json(c)
+	return
+}
+
+func SignMultisigTransaction(c *gin.Context) {
+	var req requests.SignMultisigRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apiResponse.Fail(message.ParamError).Json(c)
+		return
+	}
+	req.TxID = c.Param("txId")
+	service.SignMultisigTransaction(c, req).Json(c)
+	return
+}
+
+func AddMultisigSignature(c *gin.Context) {
+	var req requests.AddMultisigSignatureRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apiResponse.Fail(message.ParamError).Json(c)
+		return
+	}
+	req.TxID = c.Param("txId")
+	service.AddMultisigSignature(c, req).Json(c)
+	return
+}
+
+func BroadcastMultisigTransaction(c *gin.Context) {
+	service.BroadcastMultisigTransaction(c, c.Param("txId")).Json(c)
+	return
+}
+
+func CreateSafeTransaction(c *gin.Context) {
+	var req requests.CreateSafeTransactionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apiResponse.Fail(message.ParamError).Json(c)
+		return
+	}
+	service.CreateSafeTransaction(c, req).Json(c)
+	return
+}
+
+func GetSafeTransaction(c *gin.Context) {
+	service.GetSafeTransaction(c, c.Param("hash")).Json(c)
+	return
+}
+
+func SignSafeTransaction(c *gin.Context) {
+	var req requests.SignSafeTransactionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apiResponse.Fail(message.ParamError).Json(c)
+		return
+	}
+	req.SafeTxHash = c.Param("hash")
+	service.SignSafeTransaction(c, req).Json(c)
+	return
+}
+
+func AddSafeSignature(c *gin.Context) {
+	var req requests.AddSafeSignatureRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apiResponse.Fail(message.ParamError).Json(c)
+		return
+	}
+	req.SafeTxHash = c.Param("hash")
+	service.AddSafeSignature(c, req).Json(c)
+	return
+}
+
+func ExecuteSafeTransaction(c *gin.Context) {
+	var req requests.ExecuteSafeTransactionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apiResponse.Fail(message.ParamError).Json(c)
+		return
+	}
+	req.SafeTxHash = c.Param("hash")
+	service.ExecuteSafeTransaction(c, req).Json(c)
+	return
+}
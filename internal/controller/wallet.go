@@ -6,16 +6,15 @@ import (
 	"wallet/internal/message"
 	"wallet/internal/requests"
 	"wallet/internal/service"
-	"wallet/pkg/hdwallet"
 )
 
 func NewMnemonic(c *gin.Context) {
-	mnemonic, err := hdwallet.NewMnemonic()
-	if err != nil {
-		apiResponse.Fail(message.MnemonicCreateFail).Json(c)
+	var req requests.NewMnemonicRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		apiResponse.Fail(message.ParamError).Json(c)
 		return
 	}
-	apiResponse.Success(mnemonic, message.Success).Json(c)
+	service.NewMnemonic(c, req).Json(c)
 	return
 }
 
@@ -49,6 +48,16 @@ func GetTrxBalance(c *gin.Context) {
 	return
 }
 
+func TransferTrx(c *gin.Context) {
+	var req requests.TransferTRXRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apiResponse.Fail(message.ParamError).Json(c)
+		return
+	}
+	service.TransferTrx(c, req).Json(c)
+	return
+}
+
 func GetUsdtBalance(c *gin.Context) {
 	address := c.Query("address")
 	if address == "" {
@@ -59,6 +68,26 @@ func GetUsdtBalance(c *gin.Context) {
 	return
 }
 
+func GetEthBalance(c *gin.Context) {
+	address := c.Query("address")
+	if address == "" {
+		apiResponse.Fail(message.ParamError).Json(c)
+		return
+	}
+	service.GetEthBalance(c, address).Json(c)
+	return
+}
+
+func TransferETH(c *gin.Context) {
+	var req requests.TransferETHRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apiResponse.Fail(message.ParamError).Json(c)
+		return
+	}
+	service.TransferETH(c, req).Json(c)
+	return
+}
+
 func GetEthUsdtBalance(c *gin.Context) {
 	address := c.Query("address")
 	if address == "" {
@@ -68,3 +97,243 @@ func GetEthUsdtBalance(c *gin.Context) {
 	service.GetEThUsdtBalance(c, address).Json(c)
 	return
 }
+
+func ExportXpub(c *gin.Context) {
+	var req requests.ExportXpubRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apiResponse.Fail(message.ParamError).Json(c)
+		return
+	}
+	service.ExportXpub(c, req).Json(c)
+	return
+}
+
+func GetXpubAddress(c *gin.Context) {
+	var req requests.XpubAddressRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apiResponse.Fail(message.ParamError).Json(c)
+		return
+	}
+	service.GetXpubAddress(c, req).Json(c)
+	return
+}
+
+func CreateWallet(c *gin.Context) {
+	var req requests.CreateWalletRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apiResponse.Fail(message.ParamError).Json(c)
+		return
+	}
+	service.CreateWallet(c, req).Json(c)
+	return
+}
+
+func GetWallet(c *gin.Context) {
+	walletID := c.Param("walletId")
+	if walletID == "" {
+		apiResponse.Fail(message.ParamError).Json(c)
+		return
+	}
+	service.GetWallet(c, walletID).Json(c)
+	return
+}
+
+func ListWallets(c *gin.Context) {
+	var req requests.ListWalletRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		apiResponse.Fail(message.ParamError).Json(c)
+		return
+	}
+	service.ListWallets(c, req).Json(c)
+	return
+}
+
+func ExportKeystore(c *gin.Context) {
+	var req requests.ExportKeystoreRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apiResponse.Fail(message.ParamError).Json(c)
+		return
+	}
+	service.ExportKeystore(c, req).Json(c)
+	return
+}
+
+func ImportKeystore(c *gin.Context) {
+	var req requests.ImportKeystoreRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apiResponse.Fail(message.ParamError).Json(c)
+		return
+	}
+	service.ImportKeystore(c, req).Json(c)
+	return
+}
+
+func ImportPrivateKey(c *gin.Context) {
+	var req requests.ImportPrivateKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apiResponse.Fail(message.ParamError).Json(c)
+		return
+	}
+	service.ImportPrivateKey(c, req).Json(c)
+	return
+}
+
+func DiscoverAddresses(c *gin.Context) {
+	var req requests.DiscoverRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apiResponse.Fail(message.ParamError).Json(c)
+		return
+	}
+	service.DiscoverAddresses(c, req).Json(c)
+	return
+}
+
+func AllocateDepositAddresses(c *gin.Context) {
+	var req requests.AllocateDepositRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apiResponse.Fail(message.ParamError).Json(c)
+		return
+	}
+	req.WalletID = c.Param("walletId")
+	service.AllocateDepositAddresses(c, req).Json(c)
+	return
+}
+
+func SignMessage(c *gin.Context) {
+	var req requests.SignMessageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apiResponse.Fail(message.ParamError).Json(c)
+		return
+	}
+	service.SignMessage(c, req).Json(c)
+	return
+}
+
+func VerifyMessage(c *gin.Context) {
+	var req requests.VerifyMessageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apiResponse.Fail(message.ParamError).Json(c)
+		return
+	}
+	service.VerifyMessage(c, req).Json(c)
+	return
+}
+
+func HashTypedData(c *gin.Context) {
+	var req requests.TypedDataHashRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apiResponse.Fail(message.ParamError).Json(c)
+		return
+	}
+	service.HashTypedData(c, req).Json(c)
+	return
+}
+
+func SignTypedData(c *gin.Context) {
+	var req requests.SignTypedDataRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apiResponse.Fail(message.ParamError).Json(c)
+		return
+	}
+	service.SignTypedData(c, req).Json(c)
+	return
+}
+
+func GetEthTransaction(c *gin.Context) {
+	hash := c.Query("hash")
+	if hash == "" {
+		apiResponse.Fail(message.ParamError).Json(c)
+		return
+	}
+	service.GetEthTransaction(c, hash).Json(c)
+	return
+}
+
+func GetEthTokenBalance(c *gin.Context) {
+	address := c.Query("address")
+	if address == "" {
+		apiResponse.Fail(message.ParamError).Json(c)
+		return
+	}
+	service.GetEthTokenBalance(c, c.Param("symbol"), address).Json(c)
+	return
+}
+
+func TransferEthToken(c *gin.Context) {
+	var req requests.TransferTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apiResponse.Fail(message.ParamError).Json(c)
+		return
+	}
+	req.Symbol = c.Param("symbol")
+	service.TransferEthToken(c, req).Json(c)
+	return
+}
+
+func GetTronTokenBalance(c *gin.Context) {
+	address := c.Query("address")
+	if address == "" {
+		apiResponse.Fail(message.ParamError).Json(c)
+		return
+	}
+	service.GetTronTokenBalance(c, c.Param("symbol"), address).Json(c)
+	return
+}
+
+func TransferTronToken(c *gin.Context) {
+	var req requests.TransferTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apiResponse.Fail(message.ParamError).Json(c)
+		return
+	}
+	req.Symbol = c.Param("symbol")
+	service.TransferTronToken(c, req).Json(c)
+	return
+}
+
+func GetTrc10Asset(c *gin.Context) {
+	service.GetTrc10Asset(c, c.Param("assetId")).Json(c)
+	return
+}
+
+func GetTrc10Balance(c *gin.Context) {
+	address := c.Query("address")
+	if address == "" {
+		apiResponse.Fail(message.ParamError).Json(c)
+		return
+	}
+	service.GetTrc10Balance(c, c.Param("assetId"), address).Json(c)
+	return
+}
+
+func TransferTrc10(c *gin.Context) {
+	var req requests.TransferTrc10Request
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apiResponse.Fail(message.ParamError).Json(c)
+		return
+	}
+	req.AssetID = c.Param("assetId")
+	service.TransferTrc10(c, req).Json(c)
+	return
+}
+
+func GetTronResources(c *gin.Context) {
+	address := c.Query("address")
+	if address == "" {
+		apiResponse.Fail(message.ParamError).Json(c)
+		return
+	}
+	service.GetTronResources(c, address).Json(c)
+	return
+}
+
+func EstimateTronTokenFee(c *gin.Context) {
+	var req requests.EstimateTronTokenFeeRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		apiResponse.Fail(message.ParamError).Json(c)
+		return
+	}
+	req.Symbol = c.Param("symbol")
+	service.EstimateTronTokenFee(c, req).Json(c)
+	return
+}
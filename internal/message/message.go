@@ -1,12 +1,42 @@
 package message
 
 const (
-	Success              = "success"
-	Fail                 = "fail"
-	ParamError           = "param error"
-	MnemonicCreateFail   = "fail to create new mnemonic"
-	InvalidMnemonic      = "invalid mnemonic"
-	SelfTransferNotAllow = "self transfer not allow"
-	LowBalance           = "low balance"
-	InvalidAddressFormat = "invalid address format"
+	Success                 = "success"
+	Fail                    = "fail"
+	ParamError              = "param error"
+	MnemonicCreateFail      = "fail to create new mnemonic"
+	InvalidMnemonic         = "invalid mnemonic"
+	SelfTransferNotAllow    = "self transfer not allow"
+	LowBalance              = "low balance"
+	InvalidAddressFormat    = "invalid address format"
+	InvalidDerivationPath   = "invalid derivation path"
+	InvalidXpub             = "invalid extended public key"
+	WalletNotFound          = "wallet not found"
+	MnemonicInBodyDisabled  = "mnemonic in request body is disabled, use wallet_id"
+	NotHDWallet             = "wallet has no seed"
+	InvalidKeystore         = "invalid keystore or password"
+	InvalidPrivateKey       = "invalid private key"
+	RemoteSignerUnavailable = "remote signer is not configured"
+	Unauthorized            = "unauthorized"
+	InvalidSignature        = "invalid signature"
+	InvalidTypedData        = "invalid typed data"
+	ChainIDMismatch         = "typed data chainId does not match the network"
+	TransactionNotFound     = "transaction not found"
+	InvalidShare            = "invalid share"
+	NotEnoughShares         = "not enough shares"
+	ShareAddressMismatch    = "recovered mnemonic does not derive the expected address"
+	UnknownToken            = "unknown token"
+	UnknownAsset            = "unknown trc10 asset"
+	FeeEstimationFailed     = "fee estimation failed, the transaction would not succeed"
+	InvalidPermission       = "invalid account permission"
+	NotPermitted            = "key is not part of the permission"
+	AlreadySigned           = "key already signed the transaction"
+	MultisigNotPending      = "multisig transaction is no longer pending"
+	MultisigExpired         = "multisig transaction expired"
+	BroadcastFailed         = "signatures are complete but broadcast failed, retry the broadcast"
+	NotSafeOwner            = "key is not an owner of the safe"
+	SafeNonceMismatch       = "safe transaction nonce does not match the safe nonce"
+	SafeThresholdNotReached = "not enough safe owner signatures"
+	SafeExecutionFailed     = "signatures are complete but execution failed, retry the execution"
+	DepositUserAllocated    = "user was given a deposit address concurrently, retry the allocation"
 )
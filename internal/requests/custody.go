@@ -0,0 +1,23 @@
+package requests
+
+// CreateWalletRequest stores a new custodial wallet, a mnemonic is generated
+// with Words/Language unless one is supplied for import.
+type CreateWalletRequest struct {
+	Label      string `json:"label" binding:"required,max=128"`
+	Mnemonic   string `json:"mnemonic"`
+	Passphrase string `json:"passphrase"`
+	Words      int    `json:"words" binding:"omitempty,oneof=12 15 18 21 24"`
+	Language   string `json:"language" binding:"omitempty,oneof=english japanese korean chinese_simplified chinese_traditional french italian spanish czech"`
+	CreatedBy  string `json:"created_by" binding:"max=64"`
+}
+
+type ListWalletRequest struct {
+	Page int `form:"page" binding:"omitempty,min=1"`
+	Size int `form:"size" binding:"omitempty,min=1,max=100"`
+}
+
+type ImportPrivateKeyRequest struct {
+	PrivateKey string `json:"private_key" binding:"required"`
+	Label      string `json:"label" binding:"required,max=128"`
+	CreatedBy  string `json:"created_by" binding:"max=64"`
+}
@@ -0,0 +1,11 @@
+package requests
+
+// AllocateDepositRequest binds the next unused external addresses of a
+// stored HD wallet to UserIDs, users that already own an address on the
+// network keep it. WalletID comes from the path.
+type AllocateDepositRequest struct {
+	WalletID string   `json:"-"`
+	Network  int      `json:"network" binding:"required,oneof=1 2"`
+	Account  uint32   `json:"account" binding:"lt=2147483648"`
+	UserIDs  []string `json:"user_ids" binding:"required,min=1,max=1000,dive,required,max=64"`
+}
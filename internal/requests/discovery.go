@@ -0,0 +1,11 @@
+package requests
+
+// DiscoverRequest scans the used addresses of an HD wallet, Network limits
+// the scan to one chain and GapLimit overrides the configured gap limit.
+type DiscoverRequest struct {
+	WalletID   string `json:"wallet_id"`
+	Mnemonic   string `json:"mnemonic" binding:"required_without=WalletID"`
+	Passphrase string `json:"passphrase"`
+	Network    int    `json:"network" binding:"omitempty,oneof=1 2"`
+	GapLimit   uint32 `json:"gap_limit" binding:"lte=1000"`
+}
@@ -0,0 +1,18 @@
+package requests
+
+import "encoding/json"
+
+type ExportKeystoreRequest struct {
+	Network  int    `json:"network" binding:"required"`
+	Password string `json:"password" binding:"required,min=8"`
+	Kdf      string `json:"kdf" binding:"omitempty,oneof=scrypt pbkdf2"`
+	KeySource
+	DerivationParams
+}
+
+type ImportKeystoreRequest struct {
+	Keystore  json.RawMessage `json:"keystore" binding:"required"`
+	Password  string          `json:"password" binding:"required"`
+	Label     string          `json:"label" binding:"required,max=128"`
+	CreatedBy string          `json:"created_by" binding:"max=64"`
+}
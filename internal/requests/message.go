@@ -0,0 +1,22 @@
+package requests
+
+// SignMessageRequest signs Message with EIP-191 on ETH and TIP-191
+// (signMessageV2) on Tron. Encoding is utf8 unless hex is given, a hex
+// message may carry a 0x prefix.
+type SignMessageRequest struct {
+	Network  int    `json:"network" binding:"required"`
+	Message  string `json:"message" binding:"required"`
+	Encoding string `json:"encoding" binding:"omitempty,oneof=utf8 hex"`
+	KeySource
+	DerivationParams
+}
+
+// VerifyMessageRequest recovers the signer of a message signature, Address,
+// when given, is compared with the recovered one.
+type VerifyMessageRequest struct {
+	Network   int    `json:"network" binding:"required"`
+	Message   string `json:"message" binding:"required"`
+	Encoding  string `json:"encoding" binding:"omitempty,oneof=utf8 hex"`
+	Signature string `json:"signature" binding:"required"`
+	Address   string `json:"address"`
+}
@@ -0,0 +1,58 @@
+package requests
+
+import "github.com/shopspring/decimal"
+
+// CreateMultisigTransferRequest builds an unsigned transfer from a
+// multi-signature account under PermissionID, 0 is the owner permission and
+// active permissions start at 2. Symbol names the TRC-20 token of trc20
+// transfers.
+type CreateMultisigTransferRequest struct {
+	OwnerAddress      string          `json:"owner_address" binding:"required"`
+	PermissionID      int32           `json:"permission_id" binding:"gte=0,ne=1"`
+	Kind              string          `json:"kind" binding:"required,oneof=trx trc20"`
+	Symbol            string          `json:"symbol" binding:"required_if=Kind trc20"`
+	ReceiverAddress   string          `json:"receiver_address" binding:"required"`
+	Amount            decimal.Decimal `json:"amount" binding:"required"`
+	ExpirationMinutes int             `json:"expiration_minutes" binding:"omitempty,min=1,max=1440"`
+	CreatedBy         string          `json:"created_by" binding:"max=64"`
+}
+
+// PermissionParams is an owner or active permission, Keys maps base58
+// addresses to their weight.
+type PermissionParams struct {
+	Name      string           `json:"name" binding:"max=32"`
+	Threshold int64            `json:"threshold" binding:"required,min=1"`
+	Keys      map[string]int64 `json:"keys" binding:"required,min=1,max=5"`
+}
+
+// ActivePermissionParams is an active permission, Operations are the
+// contract types, e.g. TransferContract, it may sign.
+type ActivePermissionParams struct {
+	PermissionParams
+	Operations []string `json:"operations" binding:"required,min=1,dive,required"`
+}
+
+// UpdatePermissionRequest builds an unsigned permission update of
+// OwnerAddress, it is signed under the current owner permission.
+type UpdatePermissionRequest struct {
+	OwnerAddress      string                   `json:"owner_address" binding:"required"`
+	Owner             PermissionParams         `json:"owner" binding:"required"`
+	Actives           []ActivePermissionParams `json:"actives" binding:"max=8,dive"`
+	ExpirationMinutes int                      `json:"expiration_minutes" binding:"omitempty,min=1,max=1440"`
+	CreatedBy         string                   `json:"created_by" binding:"max=64"`
+}
+
+// SignMultisigRequest signs a pending transaction with a wallet key, TxID
+// comes from the path.
+type SignMultisigRequest struct {
+	TxID string `json:"-"`
+	KeySource
+	DerivationParams
+}
+
+// AddMultisigSignatureRequest adds a hex signature made outside the wallet
+// over the transaction id, TxID comes from the path.
+type AddMultisigSignatureRequest struct {
+	TxID      string `json:"-"`
+	Signature string `json:"signature" binding:"required"`
+}
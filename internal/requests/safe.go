@@ -0,0 +1,43 @@
+package requests
+
+import "github.com/shopspring/decimal"
+
+// CreateSafeTransactionRequest proposes a transfer out of the Safe at
+// SafeAddress. Symbol names the ERC-20 token of token transfers, Nonce
+// defaults to the current Safe nonce.
+type CreateSafeTransactionRequest struct {
+	SafeAddress     string          `json:"safe_address" binding:"required"`
+	Kind            string          `json:"kind" binding:"required,oneof=eth token"`
+	Symbol          string          `json:"symbol" binding:"required_if=Kind token"`
+	ReceiverAddress string          `json:"receiver_address" binding:"required"`
+	Amount          decimal.Decimal `json:"amount" binding:"required"`
+	Nonce           *uint64         `json:"nonce"`
+	CreatedBy       string          `json:"created_by" binding:"max=64"`
+}
+
+// SignSafeTransactionRequest signs a proposed Safe transaction with the key
+// of an owner, the owner also executes it once the threshold is reached.
+// SafeTxHash comes from the path.
+type SignSafeTransactionRequest struct {
+	SafeTxHash string `json:"-"`
+	KeySource
+	DerivationParams
+}
+
+// AddSafeSignatureRequest adds an owner signature made outside the wallet
+// over the Safe transaction hash, EthSign marks signatures made with
+// eth_sign/personal_sign. SafeTxHash comes from the path.
+type AddSafeSignatureRequest struct {
+	SafeTxHash string `json:"-"`
+	Signature  string `json:"signature" binding:"required"`
+	EthSign    bool   `json:"eth_sign"`
+}
+
+// ExecuteSafeTransactionRequest submits a fully signed Safe transaction,
+// the key pays the gas and need not be an owner. SafeTxHash comes from the
+// path.
+type ExecuteSafeTransactionRequest struct {
+	SafeTxHash string `json:"-"`
+	KeySource
+	DerivationParams
+}
@@ -0,0 +1,20 @@
+package requests
+
+type SplitMnemonicRequest struct {
+	Mnemonic   string `json:"mnemonic" binding:"required"`
+	Passphrase string `json:"passphrase"`
+	Threshold  int    `json:"threshold" binding:"required,min=2,max=255"`
+	Shares     int    `json:"shares" binding:"required,gtefield=Threshold,max=255"`
+}
+
+// CombineSharesRequest rebuilds a mnemonic from Shamir shares. At least one
+// expected address of m/44'/coin'/0'/0/0 is required, a Label stores the
+// recovered wallet instead of returning the mnemonic.
+type CombineSharesRequest struct {
+	Shares      []string `json:"shares" binding:"required,min=2,max=255"`
+	Passphrase  string   `json:"passphrase"`
+	TronAddress string   `json:"tron_address"`
+	EthAddress  string   `json:"eth_address" binding:"required_without=TronAddress"`
+	Label       string   `json:"label" binding:"max=128"`
+	CreatedBy   string   `json:"created_by" binding:"max=64"`
+}
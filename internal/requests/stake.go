@@ -0,0 +1,28 @@
+package requests
+
+import "github.com/shopspring/decimal"
+
+// StakeRequest stakes or unstakes Amount TRX for Resource with Stake 2.0.
+type StakeRequest struct {
+	Resource string          `json:"resource" binding:"required,oneof=energy bandwidth"`
+	Amount   decimal.Decimal `json:"amount" binding:"required"`
+	KeySource
+	DerivationParams
+}
+
+type WithdrawUnfreezeRequest struct {
+	KeySource
+	DerivationParams
+}
+
+// DelegateResourceRequest delegates, or takes back, the Resource of Amount
+// staked TRX to ReceiverAddress. LockPeriod in blocks only applies to
+// delegation.
+type DelegateResourceRequest struct {
+	ReceiverAddress string          `json:"receiver_address" binding:"required"`
+	Resource        string          `json:"resource" binding:"required,oneof=energy bandwidth"`
+	Amount          decimal.Decimal `json:"amount" binding:"required"`
+	LockPeriod      int64           `json:"lock_period" binding:"gte=0"`
+	KeySource
+	DerivationParams
+}
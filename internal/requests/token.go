@@ -0,0 +1,31 @@
+package requests
+
+import "github.com/shopspring/decimal"
+
+// TransferTokenRequest sends a registered token, Symbol comes from the path
+// and may also be the token contract.
+type TransferTokenRequest struct {
+	Symbol          string          `json:"-"`
+	ReceiverAddress string          `json:"receiver_address" binding:"required"`
+	Amount          decimal.Decimal `json:"amount" binding:"required"`
+	KeySource
+	DerivationParams
+}
+
+// TransferTrc10Request sends a TRC-10 asset, AssetID comes from the path.
+type TransferTrc10Request struct {
+	AssetID         string          `json:"-"`
+	ReceiverAddress string          `json:"receiver_address" binding:"required"`
+	Amount          decimal.Decimal `json:"amount" binding:"required"`
+	KeySource
+	DerivationParams
+}
+
+// EstimateTronTokenFeeRequest prices a TRC-20 transfer before it is sent,
+// Symbol comes from the path.
+type EstimateTronTokenFeeRequest struct {
+	Symbol          string `form:"-"`
+	SenderAddress   string `form:"sender_address" binding:"required"`
+	ReceiverAddress string `form:"receiver_address" binding:"required"`
+	Amount          string `form:"amount" binding:"required"`
+}
@@ -0,0 +1,15 @@
+package requests
+
+import "encoding/json"
+
+// TypedDataHashRequest carries an EIP-712 document with types, primaryType,
+// domain and message as eth_signTypedData_v4 takes it.
+type TypedDataHashRequest struct {
+	TypedData json.RawMessage `json:"typed_data" binding:"required"`
+}
+
+type SignTypedDataRequest struct {
+	TypedData json.RawMessage `json:"typed_data" binding:"required"`
+	KeySource
+	DerivationParams
+}
@@ -1,15 +1,65 @@
 package requests
 
-import "github.com/shopspring/decimal"
+import (
+	"encoding/json"
+
+	"github.com/shopspring/decimal"
+)
+
+// DerivationParams selects the BIP44 key of a wallet. Path, when set, takes
+// precedence over the account/change/address_index triple and must use the
+// coin type of the network, leaving all of them empty resolves to
+// m/44'/coin'/0'/0/0.
+type DerivationParams struct {
+	Account      uint32 `json:"account" binding:"lt=2147483648"`
+	Change       uint32 `json:"change" binding:"lte=1"`
+	AddressIndex uint32 `json:"address_index" binding:"lt=2147483648"`
+	Path         string `json:"path"`
+}
+
+// KeySource names the key that derives or signs: a stored wallet, a
+// mnemonic with its optional passphrase, a raw hex private key, a keystore
+// v3 file or a key held by the remote signer. Keystores and remote keys
+// ignore the derivation path.
+type KeySource struct {
+	WalletID         string          `json:"wallet_id"`
+	Mnemonic         string          `json:"mnemonic" binding:"required_without_all=WalletID PrivateKey Keystore RemoteKeyID"`
+	Passphrase       string          `json:"passphrase"`
+	PrivateKey       string          `json:"private_key"`
+	Keystore         json.RawMessage `json:"keystore"`
+	KeystorePassword string          `json:"keystore_password" binding:"required_with=Keystore"`
+	RemoteKeyID      string          `json:"remote_key_id"`
+}
+
+type NewMnemonicRequest struct {
+	Words    int    `form:"words" binding:"omitempty,oneof=12 15 18 21 24"`
+	Language string `form:"language" binding:"omitempty,oneof=english japanese korean chinese_simplified chinese_traditional french italian spanish czech"`
+}
 
 type GetAddressRequest struct {
-	Mnemonic string `json:"mnemonic" binding:"required"`
-	Network  int    `json:"network" binding:"required"`
+	Network int `json:"network" binding:"required"`
+	KeySource
+	DerivationParams
 }
 
 type TransferUSDTRequest struct {
-	Mnemonic        string          `json:"mnemonic" binding:"required"`
 	Network         int             `json:"network" binding:"required"`
 	ReceiverAddress string          `json:"receiver_address" binding:"required"`
 	Amount          decimal.Decimal `json:"amount" binding:"required"`
+	KeySource
+	DerivationParams
+}
+
+type TransferETHRequest struct {
+	ReceiverAddress string          `json:"receiver_address" binding:"required"`
+	Amount          decimal.Decimal `json:"amount" binding:"required"`
+	KeySource
+	DerivationParams
+}
+
+type TransferTRXRequest struct {
+	ReceiverAddress string          `json:"receiver_address" binding:"required"`
+	Amount          decimal.Decimal `json:"amount" binding:"required"`
+	KeySource
+	DerivationParams
 }
@@ -0,0 +1,17 @@
+package requests
+
+type ExportXpubRequest struct {
+	WalletID   string `json:"wallet_id"`
+	Mnemonic   string `json:"mnemonic" binding:"required_without=WalletID"`
+	Passphrase string `json:"passphrase"`
+	Network    int    `json:"network" binding:"required"`
+	Account    uint32 `json:"account" binding:"lt=2147483648"`
+}
+
+type XpubAddressRequest struct {
+	Xpub         string `json:"xpub" binding:"required"`
+	Network      int    `json:"network" binding:"required"`
+	Change       uint32 `json:"change" binding:"lte=1"`
+	AddressIndex uint32 `json:"address_index" binding:"lt=2147483648"`
+	Count        uint32 `json:"count" binding:"lte=1000"`
+}
@@ -13,8 +13,60 @@ func InitRouter(router gin.IRouter) {
 		route.POST("/transferUsdt", controller.TransferUSDT)
 		route.GET("/trxBalance", controller.GetTrxBalance)
 		route.GET("/usdtBalance", controller.GetUsdtBalance)
+		route.POST("/message/sign", controller.SignMessage)
+		route.POST("/message/verify", controller.VerifyMessage)
+		route.POST("/xpub", controller.ExportXpub)
+		route.POST("/xpub/address", controller.GetXpubAddress)
+		route.POST("/discover", controller.DiscoverAddresses)
+
+		wallets := route.Group("/wallets")
+		wallets.POST("", controller.CreateWallet)
+		wallets.GET("", controller.ListWallets)
+		wallets.GET("/:walletId", controller.GetWallet)
+		wallets.POST("/privateKey", controller.ImportPrivateKey)
+		wallets.POST("/:walletId/depositAddresses", controller.AllocateDepositAddresses)
+
+		route.POST("/keystore/export", controller.ExportKeystore)
+		route.POST("/keystore/import", controller.ImportKeystore)
+
+		admin := route.Group("/admin", controller.AdminAuth)
+		admin.POST("/shamir/split", controller.SplitMnemonic)
+		admin.POST("/shamir/combine", controller.CombineShares)
+		admin.POST("/tron/freeze", controller.FreezeBalance)
+		admin.POST("/tron/unfreeze", controller.UnfreezeBalance)
+		admin.POST("/tron/withdrawUnfreeze", controller.WithdrawExpireUnfreeze)
+		admin.POST("/tron/delegate", controller.DelegateResource)
+		admin.POST("/tron/undelegate", controller.UnDelegateResource)
+		admin.POST("/tron/permissions", controller.UpdateAccountPermission)
+		admin.POST("/tron/multisig", controller.CreateMultisigTransfer)
+		admin.GET("/tron/multisig/:txId", controller.GetMultisigTransaction)
+		admin.POST("/tron/multisig/:txId/sign", controller.SignMultisigTransaction)
+		admin.POST("/tron/multisig/:txId/signatures", controller.AddMultisigSignature)
+		admin.POST("/tron/multisig/:txId/broadcast", controller.BroadcastMultisigTransaction)
+		admin.POST("/eth/safe", controller.CreateSafeTransaction)
+		admin.GET("/eth/safe/:hash", controller.GetSafeTransaction)
+		admin.POST("/eth/safe/:hash/sign", controller.SignSafeTransaction)
+		admin.POST("/eth/safe/:hash/signatures", controller.AddSafeSignature)
+		admin.POST("/eth/safe/:hash/execute", controller.ExecuteSafeTransaction)
+
+		tron := route.Group("/tron")
+		tron.POST("/transfer", controller.TransferTrx)
+		tron.GET("/resources", controller.GetTronResources)
+		tron.GET("/tokens/:symbol/balance", controller.GetTronTokenBalance)
+		tron.POST("/tokens/:symbol/transfer", controller.TransferTronToken)
+		tron.GET("/tokens/:symbol/fee", controller.EstimateTronTokenFee)
+		tron.GET("/trc10/:assetId", controller.GetTrc10Asset)
+		tron.GET("/trc10/:assetId/balance", controller.GetTrc10Balance)
+		tron.POST("/trc10/:assetId/transfer", controller.TransferTrc10)
 
 		eth := route.Group("/eth")
+		eth.GET("/balance", controller.GetEthBalance)
+		eth.POST("/transfer", controller.TransferETH)
 		eth.GET("/usdtBalance", controller.GetEthUsdtBalance)
+		eth.GET("/transaction", controller.GetEthTransaction)
+		eth.GET("/tokens/:symbol/balance", controller.GetEthTokenBalance)
+		eth.POST("/tokens/:symbol/transfer", controller.TransferEthToken)
+		eth.POST("/typedData/hash", controller.HashTypedData)
+		eth.POST("/typedData/sign", controller.SignTypedData)
 	}
 }
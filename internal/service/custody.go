@@ -0,0 +1,366 @@
+package service
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/google/uuid"
+	"github.com/tyler-smith/go-bip32"
+	"gorm.io/gorm"
+	mysqlConfig "wallet/config"
+	"wallet/internal/apiResponse"
+	"wallet/internal/message"
+	"wallet/internal/requests"
+	"wallet/pkg/common/config"
+	"wallet/pkg/db/model/wallet"
+	"wallet/pkg/hdwallet"
+	"wallet/pkg/kms"
+	"wallet/pkg/signer"
+	"wallet/pkg/tools/secretbox"
+	"wallet/pkg/zlogger"
+)
+
+const rewrapBatchSize = 100
+
+var (
+	errWalletNotFound         = errors.New("wallet not found")
+	errMnemonicInBodyDisabled = errors.New("mnemonic in request body is disabled")
+	errNotHDWallet            = errors.New("wallet has no seed")
+	errNoRemoteSigner         = errors.New("remote signer is not configured")
+	errInvalidKeystore        = errors.New("invalid keystore or password")
+)
+
+type CreateWalletResult struct {
+	*wallet.WalletInfo
+	// Mnemonic is only returned once, when the service generated it.
+	Mnemonic string `json:"mnemonic,omitempty"`
+}
+
+type ImportPrivateKeyResult struct {
+	*wallet.WalletInfo
+	EthAddress  string `json:"eth_address"`
+	TronAddress string `json:"tron_address"`
+}
+
+type ListWalletResult struct {
+	List  []*wallet.WalletInfo `json:"list"`
+	Total int64                `json:"total"`
+}
+
+func CreateWallet(ctx context.Context, req requests.CreateWalletRequest) *apiResponse.Response {
+	source := wallet.SourceImported
+	mnemonic := req.Mnemonic
+	if mnemonic == "" {
+		if req.Words == 0 {
+			req.Words = hdwallet.DefaultMnemonicWords
+		}
+		if req.Language == "" {
+			req.Language = hdwallet.LanguageEnglish
+		}
+		var err error
+		mnemonic, err = hdwallet.NewMnemonic(req.Words, req.Language)
+		if err != nil {
+			return apiResponse.Fail(message.MnemonicCreateFail)
+		}
+		source = wallet.SourceGenerated
+	}
+
+	info := &wallet.WalletInfo{
+		Label:     req.Label,
+		Source:    source,
+		CreatedBy: req.CreatedBy,
+	}
+	if err := storeHDWallet(ctx, info, mnemonic, req.Passphrase); err != nil {
+		if errors.Is(err, hdwallet.ErrInvalidMnemonic) {
+			return apiResponse.Fail(message.InvalidMnemonic)
+		}
+		zlogger.Errorf("[CreateWallet] create wallet error %v", err)
+		return apiResponse.Fail(message.Fail)
+	}
+
+	result := CreateWalletResult{WalletInfo: info}
+	if source == wallet.SourceGenerated {
+		result.Mnemonic = mnemonic
+	}
+	return apiResponse.Success(result, message.Success)
+}
+
+func GetWallet(ctx context.Context, walletID string) *apiResponse.Response {
+	info, err := takeWallet(ctx, walletID)
+	if err != nil {
+		return apiResponse.Fail(keyFailMessage(err))
+	}
+	return apiResponse.Success(info, message.Success)
+}
+
+func ListWallets(ctx context.Context, req requests.ListWalletRequest) *apiResponse.Response {
+	if req.Page == 0 {
+		req.Page = 1
+	}
+	if req.Size == 0 {
+		req.Size = 20
+	}
+	list, total, err := wallet.NewWallet(mysqlConfig.DB).Find(ctx, (req.Page-1)*req.Size, req.Size)
+	if err != nil {
+		zlogger.Errorf("[ListWallets] find wallet error %v", err)
+		return apiResponse.Fail(message.Fail)
+	}
+	return apiResponse.Success(ListWalletResult{List: list, Total: total}, message.Success)
+}
+
+func ImportPrivateKey(ctx context.Context, req requests.ImportPrivateKeyRequest) *apiResponse.Response {
+	privateKey, err := hdwallet.ParsePrivateKey(req.PrivateKey)
+	if err != nil {
+		return apiResponse.Fail(message.InvalidPrivateKey)
+	}
+
+	result, err := importPrivateKey(ctx, privateKey, req.Label, req.CreatedBy)
+	if err != nil {
+		zlogger.Errorf("[ImportPrivateKey] create wallet error %v", err)
+		return apiResponse.Fail(message.Fail)
+	}
+	return apiResponse.Success(result, message.Success)
+}
+
+// loadMasterKey resolves the master key of a request, either from a stored
+// HD wallet or, unless disabled in config, from the mnemonic in the body.
+func loadMasterKey(ctx context.Context, walletID, mnemonic, passphrase string) (*bip32.Key, error) {
+	if walletID == "" {
+		if config.Config.Custody.DisableMnemonicInBody {
+			return nil, errMnemonicInBodyDisabled
+		}
+		return hdwallet.GetMasterKeyByMnemonic(mnemonic, passphrase)
+	}
+
+	info, err := takeWallet(ctx, walletID)
+	if err != nil {
+		return nil, err
+	}
+	if info.Kind != wallet.KindHD {
+		return nil, errNotHDWallet
+	}
+
+	seed, err := decryptSecret(info)
+	if err != nil {
+		return nil, err
+	}
+	defer secretbox.Zero(seed)
+
+	return hdwallet.GetMasterKeyBySeed(seed)
+}
+
+// loadKeySource resolves the key source of a request. Stored HD wallets and
+// mnemonics derive keys by path, stored or raw private keys ignore the path.
+func loadKeySource(ctx context.Context, src requests.KeySource) (hdwallet.KeySource, error) {
+	switch {
+	case src.WalletID != "":
+		info, err := takeWallet(ctx, src.WalletID)
+		if err != nil {
+			return nil, err
+		}
+		if info.Kind == wallet.KindPrivateKey {
+			keyBytes, err := decryptSecret(info)
+			if err != nil {
+				return nil, err
+			}
+			defer secretbox.Zero(keyBytes)
+			privateKey, err := crypto.ToECDSA(keyBytes)
+			if err != nil {
+				return nil, err
+			}
+			return hdwallet.NewPrivateKeySource(privateKey), nil
+		}
+	case src.PrivateKey != "":
+		if config.Config.Custody.DisableMnemonicInBody {
+			return nil, errMnemonicInBodyDisabled
+		}
+		return hdwallet.NewPrivateKeySourceFromHex(src.PrivateKey)
+	}
+
+	masterKey, err := loadMasterKey(ctx, src.WalletID, src.Mnemonic, src.Passphrase)
+	if err != nil {
+		return nil, err
+	}
+	return hdwallet.NewMasterKeySource(masterKey), nil
+}
+
+// loadPrivateKey resolves the key of src at path.
+func loadPrivateKey(ctx context.Context, src requests.KeySource, path hdwallet.DerivationPath) (*ecdsa.PrivateKey, error) {
+	source, err := loadKeySource(ctx, src)
+	if err != nil {
+		return nil, err
+	}
+	return source.PrivateKey(path)
+}
+
+// loadSigner resolves the signer of src at path. Keystores and remote keys
+// sign with their single key, every other source signs in process.
+func loadSigner(ctx context.Context, src requests.KeySource, path hdwallet.DerivationPath) (signer.Signer, error) {
+	switch {
+	case src.RemoteKeyID != "":
+		if signer.RemoteConn == nil {
+			return nil, errNoRemoteSigner
+		}
+		return signer.NewRemoteSigner(ctx, signer.RemoteConn, src.RemoteKeyID)
+	case len(src.Keystore) > 0:
+		// A keystore and its password are key material in the body just
+		// like a mnemonic.
+		if config.Config.Custody.DisableMnemonicInBody {
+			return nil, errMnemonicInBodyDisabled
+		}
+		keystoreSigner, err := signer.NewKeystoreSigner(src.Keystore, src.KeystorePassword)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", errInvalidKeystore, err)
+		}
+		return keystoreSigner, nil
+	}
+
+	privateKey, err := loadPrivateKey(ctx, src, path)
+	if err != nil {
+		return nil, err
+	}
+	return signer.NewLocalSigner(privateKey), nil
+}
+
+func takeWallet(ctx context.Context, walletID string) (*wallet.WalletInfo, error) {
+	info, err := wallet.NewWallet(mysqlConfig.DB).Take(ctx, walletID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errWalletNotFound
+		}
+		return nil, err
+	}
+	return info, nil
+}
+
+// importPrivateKey stores privateKey as a KindPrivateKey wallet.
+func importPrivateKey(ctx context.Context, privateKey *ecdsa.PrivateKey, label, createdBy string) (*ImportPrivateKeyResult, error) {
+	keyBytes := crypto.FromECDSA(privateKey)
+	defer secretbox.Zero(keyBytes)
+
+	info := &wallet.WalletInfo{
+		Label:     label,
+		Kind:      wallet.KindPrivateKey,
+		Source:    wallet.SourceImported,
+		CreatedBy: createdBy,
+	}
+	if err := storeWallet(ctx, info, keyBytes); err != nil {
+		return nil, err
+	}
+
+	return &ImportPrivateKeyResult{
+		WalletInfo:  info,
+		EthAddress:  hdwallet.DeriveEthAddress(privateKey).Hex(),
+		TronAddress: hdwallet.DeriveTronAddress(privateKey).String(),
+	}, nil
+}
+
+// storeHDWallet stores the seed of mnemonic and passphrase as a KindHD wallet.
+func storeHDWallet(ctx context.Context, info *wallet.WalletInfo, mnemonic, passphrase string) error {
+	language, err := hdwallet.DetectMnemonicLanguage(mnemonic)
+	if err != nil {
+		return err
+	}
+	seed, err := hdwallet.NewSeed(mnemonic, passphrase)
+	if err != nil {
+		return err
+	}
+	defer secretbox.Zero(seed)
+
+	info.Kind, info.Language = wallet.KindHD, language
+	return storeWallet(ctx, info, seed)
+}
+
+// storeWallet encrypts secret into info and persists it.
+func storeWallet(ctx context.Context, info *wallet.WalletInfo, secret []byte) error {
+	info.WalletID = uuid.NewString()
+	if err := encryptSecret(info, secret); err != nil {
+		return err
+	}
+	return wallet.NewWallet(mysqlConfig.DB).Create(ctx, info)
+}
+
+// keyFailMessage maps a loadMasterKey, loadPrivateKey or loadSigner error to the response message.
+func keyFailMessage(err error) string {
+	switch {
+	case errors.Is(err, errWalletNotFound):
+		return message.WalletNotFound
+	case errors.Is(err, errMnemonicInBodyDisabled):
+		return message.MnemonicInBodyDisabled
+	case errors.Is(err, errNotHDWallet):
+		return message.NotHDWallet
+	case errors.Is(err, hdwallet.ErrInvalidMnemonic):
+		return message.InvalidMnemonic
+	case errors.Is(err, hdwallet.ErrInvalidPrivateKey):
+		return message.InvalidPrivateKey
+	case errors.Is(err, errInvalidKeystore):
+		return message.InvalidKeystore
+	case errors.Is(err, errNoRemoteSigner):
+		return message.RemoteSignerUnavailable
+	default:
+		zlogger.Errorf("[loadKey] load key error %v", err)
+		return message.Fail
+	}
+}
+
+// RewrapWallets moves the data keys wrapped by from under the current
+// kms.KEK, wallets wrapped by any other KEK are skipped.
+func RewrapWallets(ctx context.Context, from kms.KeyProvider) (int, error) {
+	if kms.KEK == nil || from == nil {
+		return 0, kms.ErrNoProvider
+	}
+
+	dao := wallet.NewWallet(mysqlConfig.DB)
+	var (
+		count  int
+		lastID uint64
+	)
+	for {
+		list, err := dao.FindNotWrappedBy(ctx, kms.KEK.KeyID(), lastID, rewrapBatchSize)
+		if err != nil {
+			return count, err
+		}
+		if len(list) == 0 {
+			return count, nil
+		}
+
+		for _, info := range list {
+			lastID = info.ID
+			if info.KekID != from.KeyID() {
+				zlogger.Warnf("[RewrapWallets] wallet %s is wrapped by unknown kek %s, skipped", info.WalletID, info.KekID)
+				continue
+			}
+			envelope, err := kms.Rewrap(from, kms.KEK, secretEnvelope(info))
+			if err != nil {
+				return count, fmt.Errorf("wallet %s: %w", info.WalletID, err)
+			}
+			info.KekID, info.WrappedDataKey = envelope.KeyID, envelope.WrappedKey
+
+			if err = dao.UpdateEncryption(ctx, info.ID, info.KekID, info.WrappedDataKey, info.EncryptedSeed); err != nil {
+				return count, err
+			}
+			count++
+		}
+	}
+}
+
+func secretEnvelope(info *wallet.WalletInfo) *kms.Envelope {
+	return &kms.Envelope{KeyID: info.KekID, WrappedKey: info.WrappedDataKey, Ciphertext: info.EncryptedSeed}
+}
+
+// encryptSecret seals the seed or raw key of info under a fresh data key.
+func encryptSecret(info *wallet.WalletInfo, secret []byte) error {
+	envelope, err := kms.Seal(kms.KEK, secret, []byte(info.WalletID))
+	if err != nil {
+		return err
+	}
+	info.KekID, info.WrappedDataKey, info.EncryptedSeed = envelope.KeyID, envelope.WrappedKey, envelope.Ciphertext
+	return nil
+}
+
+func decryptSecret(info *wallet.WalletInfo) ([]byte, error) {
+	return kms.Open(kms.KEK, secretEnvelope(info), []byte(info.WalletID))
+}
@@ -0,0 +1,151 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/tyler-smith/go-bip32"
+	"gorm.io/gorm"
+	mysqlConfig "wallet/config"
+	"wallet/internal/apiResponse"
+	"wallet/internal/message"
+	"wallet/internal/requests"
+	"wallet/pkg/db/model/deposit"
+	"wallet/pkg/hdwallet"
+	"wallet/pkg/zlogger"
+)
+
+// maxAddressIndex is the first hardened index, deposit addresses are
+// derived from the account xpub and must stay below it.
+const maxAddressIndex = 1 << 31
+
+var errAddressIndexExhausted = errors.New("address index exhausted")
+
+type DepositAddress struct {
+	UserID       string `json:"user_id"`
+	Address      string `json:"address"`
+	Path         string `json:"path"`
+	AddressIndex uint32 `json:"address_index"`
+	// Allocated is false when the user already owned the address.
+	Allocated bool `json:"allocated"`
+}
+
+// AllocateDepositAddresses binds a deposit address to every user of the
+// request. The index counter row is locked for the whole transaction, so
+// concurrent allocators for the same wallet account queue up instead of
+// deriving the same index.
+func AllocateDepositAddresses(ctx context.Context, req requests.AllocateDepositRequest) *apiResponse.Response {
+	coin, err := coinType(req.Network)
+	if err != nil {
+		return apiResponse.Fail(message.ParamError)
+	}
+	masterKey, err := loadMasterKey(ctx, req.WalletID, "", "")
+	if err != nil {
+		return apiResponse.Fail(keyFailMessage(err))
+	}
+	accountKey, err := hdwallet.DeriveAccountKey(masterKey, coin, req.Account)
+	if err != nil {
+		zlogger.Errorf("[AllocateDepositAddresses] derive account error %v", err)
+		return apiResponse.Fail(message.Fail)
+	}
+	accountPublicKey := accountKey.PublicKey()
+
+	userIDs := uniqueStrings(req.UserIDs)
+	result, err := allocateDepositAddresses(ctx, req, coin, accountPublicKey, userIDs)
+	if errors.Is(err, deposit.ErrUserAllocated) {
+		// A concurrent allocation for another account bound one of the
+		// users, rerunning reads that binding back as already owned.
+		result, err = allocateDepositAddresses(ctx, req, coin, accountPublicKey, userIDs)
+	}
+	if err != nil {
+		zlogger.Errorf("[AllocateDepositAddresses] allocate wallet %s network %d error %v", req.WalletID, req.Network, err)
+		if errors.Is(err, deposit.ErrUserAllocated) {
+			return apiResponse.Fail(message.DepositUserAllocated)
+		}
+		return apiResponse.Fail(message.Fail)
+	}
+	return apiResponse.Success(result, message.Success)
+}
+
+// allocateDepositAddresses binds the missing users in one transaction.
+func allocateDepositAddresses(ctx context.Context, req requests.AllocateDepositRequest, coin uint32, accountPublicKey *bip32.Key, userIDs []string) ([]DepositAddress, error) {
+	result := make([]DepositAddress, 0, len(userIDs))
+	err := mysqlConfig.DB.Transaction(func(tx *gorm.DB) error {
+		result = result[:0]
+		dao := deposit.NewDeposit(tx)
+
+		index, err := dao.LockNextIndex(ctx, req.WalletID, req.Network, req.Account)
+		if err != nil {
+			return err
+		}
+
+		// Read the bindings under the lock, an allocation that committed
+		// while we waited is seen here.
+		existing, err := dao.FindByUsers(ctx, req.WalletID, req.Network, userIDs)
+		if err != nil {
+			return err
+		}
+		bound := make(map[string]*deposit.AddressInfo, len(existing))
+		for _, info := range existing {
+			bound[info.UserID] = info
+		}
+
+		var created []*deposit.AddressInfo
+		next := index.NextIndex
+		for _, userID := range userIDs {
+			if info, ok := bound[userID]; ok {
+				result = append(result, depositAddress(coin, info, false))
+				continue
+			}
+			if next >= maxAddressIndex {
+				return errAddressIndexExhausted
+			}
+			publicKey, err := hdwallet.DerivePublicKeyFromXpub(accountPublicKey, 0, next)
+			if err != nil {
+				return err
+			}
+			info := &deposit.AddressInfo{
+				WalletID:     req.WalletID,
+				Network:      req.Network,
+				Account:      req.Account,
+				AddressIndex: next,
+				Address:      publicKeyAddress(req.Network, publicKey),
+				UserID:       userID,
+			}
+			created = append(created, info)
+			result = append(result, depositAddress(coin, info, true))
+			next++
+		}
+		if len(created) == 0 {
+			return nil
+		}
+
+		if err = dao.CreateAddresses(ctx, created); err != nil {
+			return err
+		}
+		return dao.UpdateNextIndex(ctx, index.ID, next)
+	})
+	return result, err
+}
+
+func depositAddress(coin uint32, info *deposit.AddressInfo, allocated bool) DepositAddress {
+	return DepositAddress{
+		UserID:       info.UserID,
+		Address:      info.Address,
+		Path:         hdwallet.NewDerivationPath(coin, info.Account, 0, info.AddressIndex).String(),
+		AddressIndex: info.AddressIndex,
+		Allocated:    allocated,
+	}
+}
+
+func uniqueStrings(list []string) []string {
+	seen := make(map[string]bool, len(list))
+	out := make([]string, 0, len(list))
+	for _, s := range list {
+		if !seen[s] {
+			seen[s] = true
+			out = append(out, s)
+		}
+	}
+	return out
+}
@@ -0,0 +1,175 @@
+package service
+
+import (
+	"context"
+	"crypto/ecdsa"
+
+	"github.com/shopspring/decimal"
+	"wallet/internal/apiResponse"
+	"wallet/internal/message"
+	"wallet/internal/requests"
+	"wallet/pkg/common/config"
+	"wallet/pkg/constant"
+	"wallet/pkg/hdwallet"
+	"wallet/pkg/hdwallet/eth"
+	"wallet/pkg/hdwallet/tron"
+	"wallet/pkg/tools/blockchain"
+	"wallet/pkg/zlogger"
+)
+
+const defaultDiscoveryAccounts = 10
+
+type DiscoveredAddress struct {
+	Path         string          `json:"path"`
+	Account      uint32          `json:"account"`
+	Change       uint32          `json:"change"`
+	AddressIndex uint32          `json:"address_index"`
+	Address      string          `json:"address"`
+	Balance      decimal.Decimal `json:"balance"`
+	UsdtBalance  decimal.Decimal `json:"usdt_balance"`
+}
+
+type ChainDiscovery struct {
+	Network   int                 `json:"network"`
+	Addresses []DiscoveredAddress `json:"addresses"`
+}
+
+type DiscoverResult struct {
+	GapLimit uint32           `json:"gap_limit"`
+	Chains   []ChainDiscovery `json:"chains"`
+}
+
+// addressBalance is what a usage check learned about an address.
+type addressBalance struct {
+	address     string
+	balance     decimal.Decimal
+	usdtBalance decimal.Decimal
+}
+
+// DiscoverAddresses finds every used address of a restored HD wallet on
+// the requested chains with a BIP44 gap-limit scan.
+func DiscoverAddresses(ctx context.Context, req requests.DiscoverRequest) *apiResponse.Response {
+	masterKey, err := loadMasterKey(ctx, req.WalletID, req.Mnemonic, req.Passphrase)
+	if err != nil {
+		return apiResponse.Fail(keyFailMessage(err))
+	}
+
+	gapLimit := req.GapLimit
+	if gapLimit == 0 {
+		gapLimit = config.Config.Discovery.GapLimit
+	}
+	if gapLimit == 0 {
+		gapLimit = hdwallet.DefaultGapLimit
+	}
+	maxAccounts := config.Config.Discovery.MaxAccounts
+	if maxAccounts == 0 {
+		maxAccounts = defaultDiscoveryAccounts
+	}
+
+	networks := []int{constant.NetworkTron, constant.NetworkEth}
+	if req.Network != 0 {
+		networks = []int{req.Network}
+	}
+
+	result := DiscoverResult{GapLimit: gapLimit}
+	for _, network := range networks {
+		coin, err := coinType(network)
+		if err != nil {
+			return apiResponse.Fail(message.ParamError)
+		}
+
+		// DiscoverAddresses hands back the very public keys it checked.
+		balances := make(map[*ecdsa.PublicKey]addressBalance)
+		checker := tronUsage
+		if network == constant.NetworkEth {
+			checker = ethUsage
+		}
+		used := func(ctx context.Context, publicKey *ecdsa.PublicKey) (bool, error) {
+			balance, ok, err := checker(ctx, publicKey)
+			if ok {
+				balances[publicKey] = balance
+			}
+			return ok, err
+		}
+
+		found, err := hdwallet.DiscoverAddresses(ctx, masterKey, coin, gapLimit, maxAccounts, used)
+		if err != nil {
+			zlogger.Errorf("[DiscoverAddresses] scan network %d error %v", network, err)
+			return apiResponse.Fail(message.Fail)
+		}
+
+		chain := ChainDiscovery{Network: network, Addresses: make([]DiscoveredAddress, 0, len(found))}
+		for _, address := range found {
+			balance := balances[address.PublicKey]
+			chain.Addresses = append(chain.Addresses, DiscoveredAddress{
+				Path:         address.Path.String(),
+				Account:      address.Account,
+				Change:       address.Change,
+				AddressIndex: address.Index,
+				Address:      balance.address,
+				Balance:      balance.balance,
+				UsdtBalance:  balance.usdtBalance,
+			})
+		}
+		result.Chains = append(result.Chains, chain)
+	}
+	return apiResponse.Success(result, message.Success)
+}
+
+// tronUsage treats an address as used once its account is activated or it
+// holds USDT, a TRC-20 transfer does not activate the receiving account.
+func tronUsage(_ context.Context, publicKey *ecdsa.PublicKey) (addressBalance, bool, error) {
+	address := hdwallet.PublicKeyToTronAddress(publicKey).String()
+	account, err := tron.GetAccount(address)
+	if err != nil {
+		return addressBalance{}, false, err
+	}
+	token, err := tron.Token(tron.USDTSymbol)
+	if err != nil {
+		return addressBalance{}, false, err
+	}
+	usdt, err := tron.GetTrc20Balance(address, token.Contract)
+	if err != nil {
+		return addressBalance{}, false, err
+	}
+
+	if account == nil && usdt.Sign() == 0 {
+		return addressBalance{}, false, nil
+	}
+	return addressBalance{
+		address:     address,
+		balance:     blockchain.ToDecimal(account.GetBalance(), constant.TrxDecimals),
+		usdtBalance: blockchain.ToDecimal(usdt, token.Decimals),
+	}, true, nil
+}
+
+// ethUsage treats an address as used once it sent a transaction or holds
+// ETH or USDT.
+func ethUsage(ctx context.Context, publicKey *ecdsa.PublicKey) (addressBalance, bool, error) {
+	address := hdwallet.PublicKeyToEthAddress(publicKey)
+	nonce, err := eth.Client.GetNonce(ctx, address)
+	if err != nil {
+		return addressBalance{}, false, err
+	}
+	balance, err := eth.Client.GetBalance(ctx, address)
+	if err != nil {
+		return addressBalance{}, false, err
+	}
+	token, err := eth.Client.Token(ctx, eth.USDTSymbol)
+	if err != nil {
+		return addressBalance{}, false, err
+	}
+	usdt, err := eth.Client.GetTokenBalance(ctx, token, address)
+	if err != nil {
+		return addressBalance{}, false, err
+	}
+
+	if nonce == 0 && balance.Sign() == 0 && usdt.Sign() == 0 {
+		return addressBalance{}, false, nil
+	}
+	return addressBalance{
+		address:     address.Hex(),
+		balance:     blockchain.ToDecimal(balance, eth.Client.ETHDecimals()),
+		usdtBalance: blockchain.ToDecimal(usdt, int(token.Decimals)),
+	}, true, nil
+}
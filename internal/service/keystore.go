@@ -0,0 +1,53 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+
+	"wallet/internal/apiResponse"
+	"wallet/internal/message"
+	"wallet/internal/requests"
+	"wallet/pkg/hdwallet/keystore"
+	"wallet/pkg/tools/secretbox"
+	"wallet/pkg/zlogger"
+)
+
+func ExportKeystore(ctx context.Context, req requests.ExportKeystoreRequest) *apiResponse.Response {
+	coin, err := coinType(req.Network)
+	if err != nil {
+		return apiResponse.Fail(message.ParamError)
+	}
+	path, err := derivationPath(coin, req.DerivationParams)
+	if err != nil {
+		return apiResponse.Fail(message.InvalidDerivationPath)
+	}
+
+	privateKey, err := loadPrivateKey(ctx, req.KeySource, path)
+	if err != nil {
+		return apiResponse.Fail(keyFailMessage(err))
+	}
+	defer secretbox.ZeroKey(privateKey)
+
+	data, err := keystore.Encrypt(privateKey, req.Password, req.Kdf)
+	if err != nil {
+		zlogger.Errorf("[ExportKeystore] encrypt keystore error %v", err)
+		return apiResponse.Fail(message.Fail)
+	}
+	return apiResponse.Success(json.RawMessage(data), message.Success)
+}
+
+// ImportKeystore stores the key of a keystore v3 file as a private key
+// wallet, its wallet_id can then sign transfers on both networks.
+func ImportKeystore(ctx context.Context, req requests.ImportKeystoreRequest) *apiResponse.Response {
+	privateKey, err := keystore.Decrypt(req.Keystore, req.Password)
+	if err != nil {
+		return apiResponse.Fail(message.InvalidKeystore)
+	}
+
+	result, err := importPrivateKey(ctx, privateKey, req.Label, req.CreatedBy)
+	if err != nil {
+		zlogger.Errorf("[ImportKeystore] create wallet error %v", err)
+		return apiResponse.Fail(message.Fail)
+	}
+	return apiResponse.Success(result, message.Success)
+}
@@ -0,0 +1,111 @@
+package service
+
+import (
+	"context"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"wallet/internal/apiResponse"
+	"wallet/internal/message"
+	"wallet/internal/requests"
+	"wallet/pkg/constant"
+	"wallet/pkg/signer"
+	"wallet/pkg/zlogger"
+)
+
+type SignMessageResult struct {
+	Address   string `json:"address"`
+	Hash      string `json:"hash"`
+	Signature string `json:"signature"`
+}
+
+type VerifyMessageResult struct {
+	Address string `json:"address"`
+	// Valid is only set when the request named an expected address.
+	Valid *bool `json:"valid,omitempty"`
+}
+
+func SignMessage(ctx context.Context, req requests.SignMessageRequest) *apiResponse.Response {
+	coin, err := coinType(req.Network)
+	if err != nil {
+		return apiResponse.Fail(message.ParamError)
+	}
+	path, err := derivationPath(coin, req.DerivationParams)
+	if err != nil {
+		return apiResponse.Fail(message.InvalidDerivationPath)
+	}
+	data, err := messageBytes(req.Message, req.Encoding)
+	if err != nil {
+		return apiResponse.Fail(message.ParamError)
+	}
+
+	sender, err := loadSigner(ctx, req.KeySource, path)
+	if err != nil {
+		return apiResponse.Fail(keyFailMessage(err))
+	}
+	defer sender.Close()
+
+	hash := messageHash(req.Network, data)
+	signature, err := signer.SignMessageHash(ctx, sender, hash)
+	if err != nil {
+		zlogger.Errorf("[SignMessage] sign message error %v", err)
+		return apiResponse.Fail(message.Fail)
+	}
+	return apiResponse.Success(SignMessageResult{
+		Address:   publicKeyAddress(req.Network, sender.PublicKey()),
+		Hash:      hexutil.Encode(hash),
+		Signature: hexutil.Encode(signature),
+	}, message.Success)
+}
+
+func VerifyMessage(ctx context.Context, req requests.VerifyMessageRequest) *apiResponse.Response {
+	if _, err := coinType(req.Network); err != nil {
+		return apiResponse.Fail(message.ParamError)
+	}
+	data, err := messageBytes(req.Message, req.Encoding)
+	if err != nil {
+		return apiResponse.Fail(message.ParamError)
+	}
+	signature, err := hexutil.Decode(ensureHexPrefix(req.Signature))
+	if err != nil {
+		return apiResponse.Fail(message.InvalidSignature)
+	}
+
+	publicKey, err := signer.RecoverMessageSigner(messageHash(req.Network, data), signature)
+	if err != nil {
+		return apiResponse.Fail(message.InvalidSignature)
+	}
+
+	result := VerifyMessageResult{Address: publicKeyAddress(req.Network, publicKey)}
+	if req.Address != "" {
+		expected := req.Address
+		if req.Network == constant.NetworkEth {
+			expected = common.HexToAddress(expected).Hex()
+		}
+		valid := expected == result.Address
+		result.Valid = &valid
+	}
+	return apiResponse.Success(result, message.Success)
+}
+
+func messageHash(network int, data []byte) []byte {
+	if network == constant.NetworkTron {
+		return signer.TronMessageHash(data)
+	}
+	return signer.EthMessageHash(data)
+}
+
+func messageBytes(msg, encoding string) ([]byte, error) {
+	if encoding == "hex" {
+		return hexutil.Decode(ensureHexPrefix(msg))
+	}
+	return []byte(msg), nil
+}
+
+func ensureHexPrefix(s string) string {
+	if strings.HasPrefix(s, "0x") || strings.HasPrefix(s, "0X") {
+		return s
+	}
+	return "0x" + s
+}
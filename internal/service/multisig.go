@@ -0,0 +1,311 @@
+package service
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/fbsobreira/gotron-sdk/pkg/proto/core"
+	"google.golang.org/protobuf/proto"
+	"gorm.io/gorm"
+	mysqlConfig "wallet/config"
+	"wallet/internal/apiResponse"
+	"wallet/internal/message"
+	"wallet/internal/requests"
+	"wallet/pkg/constant"
+	"wallet/pkg/db/model/multisig"
+	"wallet/pkg/hdwallet/tron"
+	"wallet/pkg/tools/blockchain"
+	"wallet/pkg/zlogger"
+)
+
+const (
+	defaultMultisigExpiration = time.Hour
+
+	multisigKindTrx              = "trx"
+	multisigKindTrc20            = "trc20"
+	multisigKindUpdatePermission = "update_permission"
+)
+
+var errMultisigNotPending = errors.New("multisig transaction is not pending")
+
+var tronTxIDFmt = regexp.MustCompile("^[0-9a-f]{64}$")
+
+// MultisigTransaction is a stored transaction with the weight of its
+// signatures. Holders sign TxID, RawDataHex lets them check what they sign.
+type MultisigTransaction struct {
+	*multisig.Transaction
+	RawDataHex string           `json:"raw_data_hex"`
+	Weight     *tron.SignWeight `json:"weight,omitempty"`
+}
+
+func CreateMultisigTransfer(ctx context.Context, req requests.CreateMultisigTransferRequest) *apiResponse.Response {
+	for _, address := range []string{req.OwnerAddress, req.ReceiverAddress} {
+		if result, _ := validateAddressFmt(address, constant.NetworkTron); !result {
+			return apiResponse.Fail(message.InvalidAddressFormat)
+		}
+	}
+	if req.OwnerAddress == req.ReceiverAddress {
+		return apiResponse.Fail(message.SelfTransferNotAllow)
+	}
+
+	var (
+		tx  *core.Transaction
+		err error
+	)
+	switch req.Kind {
+	case multisigKindTrx:
+		amount, ok := sunAmount(req.Amount)
+		if !ok {
+			return apiResponse.Fail(message.ParamError)
+		}
+		tx, err = tron.BuildTrxTransfer(req.OwnerAddress, req.ReceiverAddress, amount)
+	case multisigKindTrc20:
+		if !req.Amount.IsPositive() {
+			return apiResponse.Fail(message.ParamError)
+		}
+		token, fail := tronToken(req.Symbol)
+		if fail != nil {
+			return fail
+		}
+		amount := blockchain.ToWei(req.Amount, token.Decimals)
+		var fee *tron.TransferFee
+		fee, err = tron.EstimateTrc20TransferFee(req.OwnerAddress, token, req.ReceiverAddress, amount)
+		if err != nil {
+			zlogger.Errorf("[CreateMultisigTransfer] estimate %s fee error %v", token.Symbol, err)
+			return apiResponse.Fail(message.FeeEstimationFailed)
+		}
+		tx, err = tron.BuildTrc20Transfer(req.OwnerAddress, token, req.ReceiverAddress, amount, fee.FeeLimit)
+	}
+	if err != nil {
+		zlogger.Errorf("[CreateMultisigTransfer] build %s transfer error %v", req.Kind, err)
+		return apiResponse.Fail(message.Fail)
+	}
+	return storeMultisigTransaction(ctx, tx, req.OwnerAddress, req.PermissionID, req.Kind, req.ExpirationMinutes, req.CreatedBy)
+}
+
+// UpdateAccountPermission builds the permission update as a pending owner
+// transaction, it takes effect once enough owner keys signed it.
+func UpdateAccountPermission(ctx context.Context, req requests.UpdatePermissionRequest) *apiResponse.Response {
+	if result, _ := validateAddressFmt(req.OwnerAddress, constant.NetworkTron); !result {
+		return apiResponse.Fail(message.InvalidAddressFormat)
+	}
+	owner := tronPermission(req.Owner, nil)
+	actives := make([]tron.Permission, 0, len(req.Actives))
+	for _, active := range req.Actives {
+		actives = append(actives, tronPermission(active.PermissionParams, active.Operations))
+	}
+
+	tx, err := tron.BuildUpdatePermission(req.OwnerAddress, owner, actives)
+	if err != nil {
+		zlogger.Errorf("[UpdateAccountPermission] build update of %s error %v", req.OwnerAddress, err)
+		return apiResponse.Fail(message.InvalidPermission)
+	}
+	return storeMultisigTransaction(ctx, tx, req.OwnerAddress, 0, multisigKindUpdatePermission, req.ExpirationMinutes, req.CreatedBy)
+}
+
+func tronPermission(params requests.PermissionParams, operations []string) tron.Permission {
+	return tron.Permission{Name: params.Name, Threshold: params.Threshold, Keys: params.Keys, Operations: operations}
+}
+
+func storeMultisigTransaction(ctx context.Context, tx *core.Transaction, ownerAddress string, permissionID int32, kind string, expirationMinutes int, createdBy string) *apiResponse.Response {
+	expiration := time.Now().Add(defaultMultisigExpiration)
+	if expirationMinutes > 0 {
+		expiration = time.Now().Add(min(time.Duration(expirationMinutes)*time.Minute, tron.MaxMultisigExpiration))
+	}
+	txID, err := tron.PrepareMultisig(tx, permissionID, expiration)
+	if err != nil {
+		zlogger.Errorf("[storeMultisigTransaction] prepare error %v", err)
+		return apiResponse.Fail(message.Fail)
+	}
+	raw, err := proto.Marshal(tx)
+	if err != nil {
+		zlogger.Errorf("[storeMultisigTransaction] marshal error %v", err)
+		return apiResponse.Fail(message.Fail)
+	}
+
+	record := &multisig.Transaction{
+		TxID:           hex.EncodeToString(txID),
+		OwnerAddress:   ownerAddress,
+		PermissionID:   permissionID,
+		Kind:           kind,
+		RawTransaction: raw,
+		Status:         multisig.StatusPending,
+		CreatedBy:      createdBy,
+		Expiration:     expiration,
+	}
+	if err = multisig.NewMultisig(mysqlConfig.DB).Create(ctx, record); err != nil {
+		zlogger.Errorf("[storeMultisigTransaction] create error %v", err)
+		return apiResponse.Fail(message.Fail)
+	}
+	return apiResponse.Success(multisigTransaction(record, tx, nil), message.Success)
+}
+
+func GetMultisigTransaction(ctx context.Context, txID string) *apiResponse.Response {
+	if !tronTxIDFmt.MatchString(txID) {
+		return apiResponse.Fail(message.ParamError)
+	}
+	record, err := multisig.NewMultisig(mysqlConfig.DB).FindByTxID(ctx, txID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return apiResponse.Fail(message.TransactionNotFound)
+		}
+		zlogger.Errorf("[GetMultisigTransaction] find %s error %v", txID, err)
+		return apiResponse.Fail(message.Fail)
+	}
+	tx := new(core.Transaction)
+	if err = proto.Unmarshal(record.RawTransaction, tx); err != nil {
+		zlogger.Errorf("[GetMultisigTransaction] unmarshal %s error %v", txID, err)
+		return apiResponse.Fail(message.Fail)
+	}
+
+	var weight *tron.SignWeight
+	if record.Status == multisig.StatusPending {
+		if weight, err = tron.GetSignWeight(tx); err != nil {
+			zlogger.Errorf("[GetMultisigTransaction] sign weight of %s error %v", txID, err)
+			return apiResponse.Fail(message.Fail)
+		}
+	}
+	return apiResponse.Success(multisigTransaction(record, tx, weight), message.Success)
+}
+
+// SignMultisigTransaction adds the signature of a wallet key.
+func SignMultisigTransaction(ctx context.Context, req requests.SignMultisigRequest) *apiResponse.Response {
+	if !tronTxIDFmt.MatchString(req.TxID) {
+		return apiResponse.Fail(message.ParamError)
+	}
+	path, err := derivationPath(constant.CoinTron, req.DerivationParams)
+	if err != nil {
+		return apiResponse.Fail(message.InvalidDerivationPath)
+	}
+	holder, err := loadSigner(ctx, req.KeySource, path)
+	if err != nil {
+		return apiResponse.Fail(keyFailMessage(err))
+	}
+	defer holder.Close()
+
+	return collectSignature(ctx, req.TxID, func(tx *core.Transaction, weight *tron.SignWeight) error {
+		return tron.Sign(ctx, tx, weight, holder)
+	})
+}
+
+// AddMultisigSignature adds a signature made outside the wallet, e.g. on a
+// hardware device, over the transaction id.
+func AddMultisigSignature(ctx context.Context, req requests.AddMultisigSignatureRequest) *apiResponse.Response {
+	if !tronTxIDFmt.MatchString(req.TxID) {
+		return apiResponse.Fail(message.ParamError)
+	}
+	signature, err := hex.DecodeString(strings.TrimPrefix(req.Signature, "0x"))
+	if err != nil {
+		return apiResponse.Fail(message.InvalidSignature)
+	}
+	return collectSignature(ctx, req.TxID, func(tx *core.Transaction, weight *tron.SignWeight) error {
+		return tron.AddSignature(tx, weight, signature)
+	})
+}
+
+// BroadcastMultisigTransaction retries the broadcast of a transaction whose
+// signatures already reach the threshold.
+func BroadcastMultisigTransaction(ctx context.Context, txID string) *apiResponse.Response {
+	if !tronTxIDFmt.MatchString(txID) {
+		return apiResponse.Fail(message.ParamError)
+	}
+	return collectSignature(ctx, txID, func(*core.Transaction, *tron.SignWeight) error {
+		return nil
+	})
+}
+
+// collectSignature lets add sign the pending transaction txID under its row
+// lock and stores the result. Once the signatures carry the threshold weight
+// it is broadcast after the lock is released, so a slow node never holds up
+// the other signers. A failed broadcast keeps the signatures, it can be
+// retried with BroadcastMultisigTransaction.
+func collectSignature(ctx context.Context, txID string, add func(tx *core.Transaction, weight *tron.SignWeight) error) *apiResponse.Response {
+	var (
+		result MultisigTransaction
+		tx     *core.Transaction
+	)
+	err := mysqlConfig.DB.Transaction(func(db *gorm.DB) error {
+		dao := multisig.NewMultisig(db)
+		record, err := dao.LockByTxID(ctx, txID)
+		if err != nil {
+			return err
+		}
+		if record.Status != multisig.StatusPending {
+			return errMultisigNotPending
+		}
+		if record.Expire(time.Now()) {
+			// Committed on purpose, the error is reported after commit.
+			result.Transaction = record
+			return dao.Update(ctx, record.ID, record.RawTransaction, record.Status)
+		}
+
+		tx = new(core.Transaction)
+		if err = proto.Unmarshal(record.RawTransaction, tx); err != nil {
+			return err
+		}
+		weight, err := tron.GetSignWeight(tx)
+		if err != nil {
+			return err
+		}
+		if err = add(tx, weight); err != nil {
+			return err
+		}
+		if weight, err = tron.GetSignWeight(tx); err != nil {
+			return err
+		}
+
+		raw, err := proto.Marshal(tx)
+		if err != nil {
+			return err
+		}
+		if err = dao.Update(ctx, record.ID, raw, record.Status); err != nil {
+			return err
+		}
+		record.RawTransaction = raw
+		result = multisigTransaction(record, tx, weight)
+		return nil
+	})
+	switch {
+	case err == nil:
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return apiResponse.Fail(message.TransactionNotFound)
+	case errors.Is(err, errMultisigNotPending):
+		return apiResponse.Fail(message.MultisigNotPending)
+	case errors.Is(err, tron.ErrNotPermitted):
+		return apiResponse.Fail(message.NotPermitted)
+	case errors.Is(err, tron.ErrAlreadySigned):
+		return apiResponse.Fail(message.AlreadySigned)
+	case errors.Is(err, tron.ErrInvalidSignatureFormat):
+		return apiResponse.Fail(message.InvalidSignature)
+	default:
+		zlogger.Errorf("[collectSignature] sign %s error %v", txID, err)
+		return apiResponse.Fail(message.Fail)
+	}
+	if result.Status == multisig.StatusExpired {
+		return apiResponse.Fail(message.MultisigExpired)
+	}
+	if !result.Weight.Enough {
+		return apiResponse.Success(result, message.Success)
+	}
+
+	if err = tron.Broadcast(tx); err != nil {
+		zlogger.Errorf("[collectSignature] broadcast %s error %v", txID, err)
+		return apiResponse.Fail(message.BroadcastFailed)
+	}
+	err = multisig.NewMultisig(mysqlConfig.DB).UpdateStatus(ctx, result.ID, multisig.StatusPending, multisig.StatusBroadcast)
+	if err != nil {
+		zlogger.Errorf("[collectSignature] mark %s broadcast error %v", txID, err)
+		return apiResponse.Fail(message.Fail)
+	}
+	result.Status = multisig.StatusBroadcast
+	return apiResponse.Success(result, message.Success)
+}
+
+func multisigTransaction(record *multisig.Transaction, tx *core.Transaction, weight *tron.SignWeight) MultisigTransaction {
+	rawData, _ := proto.Marshal(tx.GetRawData())
+	return MultisigTransaction{Transaction: record, RawDataHex: hex.EncodeToString(rawData), Weight: weight}
+}
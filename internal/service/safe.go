@@ -0,0 +1,409 @@
+package service
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"regexp"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"gorm.io/gorm"
+	mysqlConfig "wallet/config"
+	"wallet/internal/apiResponse"
+	"wallet/internal/message"
+	"wallet/internal/requests"
+	"wallet/pkg/constant"
+	"wallet/pkg/db/model/multisig"
+	"wallet/pkg/hdwallet/eth"
+	"wallet/pkg/signer"
+	"wallet/pkg/tools/blockchain"
+	"wallet/pkg/zlogger"
+)
+
+const (
+	safeKindEth   = "eth"
+	safeKindToken = "token"
+)
+
+var (
+	errNotSafeOwner      = errors.New("signer is not a safe owner")
+	errSafeAlreadySigned = errors.New("owner already signed the safe transaction")
+	errSafeNonceMismatch = errors.New("safe transaction nonce does not match the safe nonce")
+)
+
+var safeTxHashFmt = regexp.MustCompile("^0x[0-9a-f]{64}$")
+
+// SafeTransactionResult is a stored Safe transaction with its signatures.
+// Owners sign SafeTxHash, Tx lets them check what they sign.
+type SafeTransactionResult struct {
+	*multisig.SafeTransaction
+	Tx         *eth.SafeTransaction `json:"transaction"`
+	Signatures []eth.SafeSignature  `json:"signatures"`
+	Threshold  uint64               `json:"threshold,omitempty"`
+	// Dropped are signers removed from the Safe owners since they signed,
+	// their signatures were discarded.
+	Dropped []common.Address `json:"dropped,omitempty"`
+}
+
+func CreateSafeTransaction(ctx context.Context, req requests.CreateSafeTransactionRequest) *apiResponse.Response {
+	for _, address := range []string{req.SafeAddress, req.ReceiverAddress} {
+		if result, _ := validateAddressFmt(address, constant.NetworkEth); !result {
+			return apiResponse.Fail(message.InvalidAddressFormat)
+		}
+	}
+	if strings.EqualFold(req.SafeAddress, req.ReceiverAddress) {
+		return apiResponse.Fail(message.SelfTransferNotAllow)
+	}
+	if !req.Amount.IsPositive() {
+		return apiResponse.Fail(message.ParamError)
+	}
+	safe := common.HexToAddress(req.SafeAddress)
+	receiver := common.HexToAddress(req.ReceiverAddress)
+
+	info, err := eth.Client.GetSafeInfo(ctx, safe)
+	if err != nil {
+		zlogger.Errorf("[CreateSafeTransaction] get safe %s error %v", safe.Hex(), err)
+		return apiResponse.Fail(message.Fail)
+	}
+	nonce := info.Nonce
+	if req.Nonce != nil {
+		nonce = new(big.Int).SetUint64(*req.Nonce)
+		if nonce.Cmp(info.Nonce) < 0 {
+			return apiResponse.Fail(message.SafeNonceMismatch)
+		}
+	}
+
+	var (
+		tx      *eth.SafeTransaction
+		amount  *big.Int
+		balance *big.Int
+	)
+	switch req.Kind {
+	case safeKindEth:
+		amount = blockchain.ToWei(req.Amount, eth.Client.ETHDecimals())
+		balance, err = eth.Client.GetBalance(ctx, safe)
+		tx = eth.NewSafeEthTransfer(receiver, amount, nonce)
+	case safeKindToken:
+		token, fail := ethToken(ctx, req.Symbol)
+		if fail != nil {
+			return fail
+		}
+		amount = blockchain.ToWei(req.Amount, int(token.Decimals))
+		balance, err = eth.Client.GetTokenBalance(ctx, token, safe)
+		tx = eth.NewSafeTokenTransfer(token, receiver, amount, nonce)
+	}
+	if err != nil {
+		zlogger.Errorf("[CreateSafeTransaction] get %s balance of %s error %v", req.Kind, safe.Hex(), err)
+		return apiResponse.Fail(message.Fail)
+	}
+	if balance.Cmp(amount) < 0 {
+		zlogger.Warnf("Available Balance safe %s, %s balance %v, transfer amount %v", safe.Hex(), req.Kind, balance, amount)
+		return apiResponse.Fail(message.LowBalance)
+	}
+
+	chainID, err := eth.Client.GetChainID(ctx)
+	if err != nil {
+		zlogger.Errorf("[CreateSafeTransaction] get chain id error %v", err)
+		return apiResponse.Fail(message.Fail)
+	}
+	encoded, err := json.Marshal(tx)
+	if err != nil {
+		zlogger.Errorf("[CreateSafeTransaction] marshal error %v", err)
+		return apiResponse.Fail(message.Fail)
+	}
+
+	record := &multisig.SafeTransaction{
+		SafeTxHash:  tx.Hash(chainID, safe).Hex(),
+		SafeAddress: safe.Hex(),
+		ChainID:     chainID.Uint64(),
+		Kind:        req.Kind,
+		Transaction: string(encoded),
+		Signatures:  "[]",
+		Status:      multisig.StatusPending,
+		CreatedBy:   req.CreatedBy,
+	}
+	if err = multisig.NewMultisig(mysqlConfig.DB).CreateSafe(ctx, record); err != nil {
+		zlogger.Errorf("[CreateSafeTransaction] create error %v", err)
+		return apiResponse.Fail(message.Fail)
+	}
+	return apiResponse.Success(SafeTransactionResult{SafeTransaction: record, Tx: tx, Signatures: []eth.SafeSignature{}, Threshold: info.Threshold}, message.Success)
+}
+
+func GetSafeTransaction(ctx context.Context, hash string) *apiResponse.Response {
+	hash = strings.ToLower(hash)
+	if !safeTxHashFmt.MatchString(hash) {
+		return apiResponse.Fail(message.ParamError)
+	}
+	record, err := multisig.NewMultisig(mysqlConfig.DB).FindSafeByHash(ctx, hash)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return apiResponse.Fail(message.TransactionNotFound)
+		}
+		zlogger.Errorf("[GetSafeTransaction] find %s error %v", hash, err)
+		return apiResponse.Fail(message.Fail)
+	}
+	result, err := safeTransactionResult(record)
+	if err != nil {
+		zlogger.Errorf("[GetSafeTransaction] decode %s error %v", hash, err)
+		return apiResponse.Fail(message.Fail)
+	}
+	if record.Status == multisig.StatusPending {
+		info, err := eth.Client.GetSafeInfo(ctx, common.HexToAddress(record.SafeAddress))
+		if err != nil {
+			zlogger.Errorf("[GetSafeTransaction] get safe %s error %v", record.SafeAddress, err)
+			return apiResponse.Fail(message.Fail)
+		}
+		result.Threshold = info.Threshold
+	}
+	return apiResponse.Success(result, message.Success)
+}
+
+// SignSafeTransaction adds the signature of a wallet key that owns the Safe,
+// the key then executes the transaction if the signatures reach the
+// threshold.
+func SignSafeTransaction(ctx context.Context, req requests.SignSafeTransactionRequest) *apiResponse.Response {
+	hash := strings.ToLower(req.SafeTxHash)
+	if !safeTxHashFmt.MatchString(hash) {
+		return apiResponse.Fail(message.ParamError)
+	}
+	path, err := derivationPath(constant.CoinEth, req.DerivationParams)
+	if err != nil {
+		return apiResponse.Fail(message.InvalidDerivationPath)
+	}
+	owner, err := loadSigner(ctx, req.KeySource, path)
+	if err != nil {
+		return apiResponse.Fail(keyFailMessage(err))
+	}
+	defer owner.Close()
+
+	return collectSafeSignature(ctx, hash, func(safeTxHash common.Hash) (*eth.SafeSignature, error) {
+		return eth.SignSafeTransaction(ctx, owner, safeTxHash)
+	}, owner)
+}
+
+// AddSafeSignature adds an owner signature made outside the wallet, e.g. by
+// a hardware wallet, over the Safe transaction hash. The transaction is not
+// executed, ExecuteSafeTransaction does once the threshold is reached.
+func AddSafeSignature(ctx context.Context, req requests.AddSafeSignatureRequest) *apiResponse.Response {
+	hash := strings.ToLower(req.SafeTxHash)
+	if !safeTxHashFmt.MatchString(hash) {
+		return apiResponse.Fail(message.ParamError)
+	}
+	signature, err := hex.DecodeString(strings.TrimPrefix(req.Signature, "0x"))
+	if err != nil {
+		return apiResponse.Fail(message.InvalidSignature)
+	}
+	return collectSafeSignature(ctx, hash, func(safeTxHash common.Hash) (*eth.SafeSignature, error) {
+		return eth.RecoverSafeSignature(safeTxHash, signature, req.EthSign)
+	}, nil)
+}
+
+// ExecuteSafeTransaction submits a Safe transaction whose signatures reach
+// the threshold, the key pays the gas.
+func ExecuteSafeTransaction(ctx context.Context, req requests.ExecuteSafeTransactionRequest) *apiResponse.Response {
+	hash := strings.ToLower(req.SafeTxHash)
+	if !safeTxHashFmt.MatchString(hash) {
+		return apiResponse.Fail(message.ParamError)
+	}
+	path, err := derivationPath(constant.CoinEth, req.DerivationParams)
+	if err != nil {
+		return apiResponse.Fail(message.InvalidDerivationPath)
+	}
+	executor, err := loadSigner(ctx, req.KeySource, path)
+	if err != nil {
+		return apiResponse.Fail(keyFailMessage(err))
+	}
+	defer executor.Close()
+
+	return collectSafeSignature(ctx, hash, nil, executor)
+}
+
+// collectSafeSignature adds the signature sign makes over the pending Safe
+// transaction hash. With an executor the transaction is submitted once the
+// signatures reach the threshold and its nonce is the Safe nonce, a
+// transaction queued behind others keeps collecting signatures. Without
+// sign the executor must be able to submit it. A failed execution keeps the
+// signatures, it can be retried with ExecuteSafeTransaction.
+//
+// The node is only asked outside the row lock, a slow node must not hold up
+// the other owners: the signatures are committed first and the transaction
+// is executed afterwards.
+func collectSafeSignature(ctx context.Context, hash string, sign func(safeTxHash common.Hash) (*eth.SafeSignature, error), executor signer.Signer) *apiResponse.Response {
+	record, err := multisig.NewMultisig(mysqlConfig.DB).FindSafeByHash(ctx, hash)
+	if err != nil {
+		return safeSignatureFail(hash, err)
+	}
+	if record.Status != multisig.StatusPending {
+		return safeSignatureFail(hash, errMultisigNotPending)
+	}
+	result, err := safeTransactionResult(record)
+	if err != nil {
+		return safeSignatureFail(hash, err)
+	}
+	safe := common.HexToAddress(record.SafeAddress)
+	info, err := eth.Client.GetSafeInfo(ctx, safe)
+	if err != nil {
+		return safeSignatureFail(hash, err)
+	}
+	// Another transaction took the nonce, this one can never execute.
+	if result.Tx.Nonce.ToInt().Cmp(info.Nonce) < 0 {
+		return safeSignatureFail(hash, errSafeNonceMismatch)
+	}
+
+	var signature *eth.SafeSignature
+	if sign != nil {
+		if signature, err = sign(common.HexToHash(hash)); err != nil {
+			return safeSignatureFail(hash, err)
+		}
+		owner, err := eth.Client.IsSafeOwner(ctx, safe, signature.Owner)
+		if err != nil {
+			return safeSignatureFail(hash, err)
+		}
+		if !owner {
+			return safeSignatureFail(hash, errNotSafeOwner)
+		}
+	}
+	stale, err := staleSigners(ctx, safe, result.Signatures)
+	if err != nil {
+		return safeSignatureFail(hash, err)
+	}
+
+	err = mysqlConfig.DB.Transaction(func(db *gorm.DB) error {
+		dao := multisig.NewMultisig(db)
+		record, err := dao.LockSafeByHash(ctx, hash)
+		if err != nil {
+			return err
+		}
+		if record.Status != multisig.StatusPending {
+			return errMultisigNotPending
+		}
+		if result, err = safeTransactionResult(record); err != nil {
+			return err
+		}
+		// Signatures added since were checked by the request adding them.
+		dropSignatures(&result, stale)
+		if signature != nil {
+			for _, signed := range result.Signatures {
+				if signed.Owner == signature.Owner {
+					return errSafeAlreadySigned
+				}
+			}
+			result.Signatures = append(result.Signatures, *signature)
+		} else if len(result.Dropped) == 0 {
+			return nil
+		}
+
+		signatures, err := json.Marshal(result.Signatures)
+		if err != nil {
+			return err
+		}
+		record.Signatures = string(signatures)
+		return dao.UpdateSafe(ctx, record.ID, record.Signatures, record.Status, record.ExecTxID)
+	})
+	if err != nil {
+		return safeSignatureFail(hash, err)
+	}
+	result.Threshold = info.Threshold
+
+	ready := uint64(len(result.Signatures)) >= info.Threshold && result.Tx.Nonce.ToInt().Cmp(info.Nonce) == 0
+	if sign == nil && !ready {
+		if uint64(len(result.Signatures)) < info.Threshold {
+			return safeSignatureFail(hash, eth.ErrSafeThreshold)
+		}
+		return safeSignatureFail(hash, errSafeNonceMismatch)
+	}
+	if executor == nil || !ready {
+		return apiResponse.Success(result, message.Success)
+	}
+
+	if err = executeSafeTransaction(ctx, executor, safe, &result); err != nil {
+		zlogger.Errorf("[collectSafeSignature] execute %s error %v", hash, err)
+		return apiResponse.Fail(message.SafeExecutionFailed)
+	}
+	err = multisig.NewMultisig(mysqlConfig.DB).UpdateSafeStatus(ctx, result.ID, multisig.StatusPending, multisig.StatusExecuted, result.ExecTxID)
+	if err != nil {
+		zlogger.Errorf("[collectSafeSignature] mark %s executed by %s error %v", hash, result.ExecTxID, err)
+		return apiResponse.Fail(message.Fail)
+	}
+	return apiResponse.Success(result, message.Success)
+}
+
+// safeSignatureFail maps a collectSafeSignature error to the response.
+func safeSignatureFail(hash string, err error) *apiResponse.Response {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return apiResponse.Fail(message.TransactionNotFound)
+	case errors.Is(err, errMultisigNotPending):
+		return apiResponse.Fail(message.MultisigNotPending)
+	case errors.Is(err, errSafeNonceMismatch):
+		return apiResponse.Fail(message.SafeNonceMismatch)
+	case errors.Is(err, errNotSafeOwner):
+		return apiResponse.Fail(message.NotSafeOwner)
+	case errors.Is(err, errSafeAlreadySigned):
+		return apiResponse.Fail(message.AlreadySigned)
+	case errors.Is(err, eth.ErrSafeSignatureFormat):
+		return apiResponse.Fail(message.InvalidSignature)
+	case errors.Is(err, eth.ErrSafeThreshold):
+		return apiResponse.Fail(message.SafeThresholdNotReached)
+	default:
+		zlogger.Errorf("[collectSafeSignature] sign %s error %v", hash, err)
+		return apiResponse.Fail(message.Fail)
+	}
+}
+
+// staleSigners returns the signers of signatures that are no longer owners
+// of safe, the Safe would reject their signatures on execution.
+func staleSigners(ctx context.Context, safe common.Address, signatures []eth.SafeSignature) (map[common.Address]bool, error) {
+	stale := make(map[common.Address]bool)
+	for _, signed := range signatures {
+		owner, err := eth.Client.IsSafeOwner(ctx, safe, signed.Owner)
+		if err != nil {
+			return nil, err
+		}
+		if !owner {
+			zlogger.Warnf("[staleSigners] %s is no longer an owner of safe %s", signed.Owner.Hex(), safe.Hex())
+			stale[signed.Owner] = true
+		}
+	}
+	return stale, nil
+}
+
+// dropSignatures discards the signatures of the stale signers.
+func dropSignatures(result *SafeTransactionResult, stale map[common.Address]bool) {
+	signatures := result.Signatures[:0]
+	for _, signed := range result.Signatures {
+		if stale[signed.Owner] {
+			result.Dropped = append(result.Dropped, signed.Owner)
+			continue
+		}
+		signatures = append(signatures, signed)
+	}
+	result.Signatures = signatures
+}
+
+func executeSafeTransaction(ctx context.Context, executor signer.Signer, safe common.Address, result *SafeTransactionResult) error {
+	fee, err := eth.Client.SuggestGasFee(ctx)
+	if err != nil {
+		return err
+	}
+	transfer, err := eth.Client.ExecSafeTransaction(ctx, executor, safe, result.Tx, result.Signatures, fee)
+	if err != nil {
+		return err
+	}
+	result.Status, result.ExecTxID = multisig.StatusExecuted, transfer.TxID
+	return nil
+}
+
+func safeTransactionResult(record *multisig.SafeTransaction) (SafeTransactionResult, error) {
+	result := SafeTransactionResult{SafeTransaction: record, Tx: new(eth.SafeTransaction)}
+	if err := json.Unmarshal([]byte(record.Transaction), result.Tx); err != nil {
+		return result, err
+	}
+	if err := json.Unmarshal([]byte(record.Signatures), &result.Signatures); err != nil {
+		return result, err
+	}
+	return result, nil
+}
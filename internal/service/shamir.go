@@ -0,0 +1,119 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ethereum/go-ethereum/common"
+	"wallet/internal/apiResponse"
+	"wallet/internal/message"
+	"wallet/internal/requests"
+	"wallet/pkg/common/config"
+	"wallet/pkg/constant"
+	"wallet/pkg/db/model/wallet"
+	"wallet/pkg/hdwallet"
+	"wallet/pkg/zlogger"
+)
+
+type SplitMnemonicResult struct {
+	Shares    []string `json:"shares"`
+	Threshold int      `json:"threshold"`
+	// The addresses of m/44'/coin'/0'/0/0, record them with the shares so
+	// CombineShares can verify the recovery.
+	TronAddress string `json:"tron_address"`
+	EthAddress  string `json:"eth_address"`
+}
+
+type CombineSharesResult struct {
+	*wallet.WalletInfo
+	// Mnemonic is only returned when the recovered wallet is not stored.
+	Mnemonic    string `json:"mnemonic,omitempty"`
+	TronAddress string `json:"tron_address"`
+	EthAddress  string `json:"eth_address"`
+}
+
+func SplitMnemonic(ctx context.Context, req requests.SplitMnemonicRequest) *apiResponse.Response {
+	tronAddress, ethAddress, err := defaultAddresses(req.Mnemonic, req.Passphrase)
+	if err != nil {
+		return apiResponse.Fail(message.InvalidMnemonic)
+	}
+
+	shares, err := hdwallet.SplitMnemonic(req.Mnemonic, req.Threshold, req.Shares)
+	if err != nil {
+		zlogger.Errorf("[SplitMnemonic] split mnemonic error %v", err)
+		return apiResponse.Fail(message.Fail)
+	}
+	return apiResponse.Success(SplitMnemonicResult{
+		Shares:      shares,
+		Threshold:   req.Threshold,
+		TronAddress: tronAddress,
+		EthAddress:  ethAddress,
+	}, message.Success)
+}
+
+// CombineShares rebuilds a mnemonic and only accepts it when it derives the
+// expected addresses, any other set of shares is rejected.
+func CombineShares(ctx context.Context, req requests.CombineSharesRequest) *apiResponse.Response {
+	if req.Label == "" && config.Config.Custody.DisableMnemonicInBody {
+		return apiResponse.Fail(message.MnemonicInBodyDisabled)
+	}
+
+	mnemonic, err := hdwallet.CombineMnemonic(req.Shares)
+	if err != nil {
+		switch {
+		case errors.Is(err, hdwallet.ErrNotEnoughShares):
+			return apiResponse.Fail(message.NotEnoughShares)
+		case errors.Is(err, hdwallet.ErrInvalidShare), errors.Is(err, hdwallet.ErrShareMismatch):
+			return apiResponse.Fail(message.InvalidShare)
+		default:
+			zlogger.Errorf("[CombineShares] combine shares error %v", err)
+			return apiResponse.Fail(message.Fail)
+		}
+	}
+
+	tronAddress, ethAddress, err := defaultAddresses(mnemonic, req.Passphrase)
+	if err != nil {
+		zlogger.Errorf("[CombineShares] derive address error %v", err)
+		return apiResponse.Fail(message.Fail)
+	}
+	if req.TronAddress != "" && req.TronAddress != tronAddress {
+		return apiResponse.Fail(message.ShareAddressMismatch)
+	}
+	if req.EthAddress != "" && common.HexToAddress(req.EthAddress).Hex() != ethAddress {
+		return apiResponse.Fail(message.ShareAddressMismatch)
+	}
+
+	result := CombineSharesResult{TronAddress: tronAddress, EthAddress: ethAddress}
+	if req.Label == "" {
+		result.Mnemonic = mnemonic
+		return apiResponse.Success(result, message.Success)
+	}
+
+	result.WalletInfo = &wallet.WalletInfo{
+		Label:     req.Label,
+		Source:    wallet.SourceRecovered,
+		CreatedBy: req.CreatedBy,
+	}
+	if err = storeHDWallet(ctx, result.WalletInfo, mnemonic, req.Passphrase); err != nil {
+		zlogger.Errorf("[CombineShares] create wallet error %v", err)
+		return apiResponse.Fail(message.Fail)
+	}
+	return apiResponse.Success(result, message.Success)
+}
+
+// defaultAddresses derives the Tron and ETH addresses of m/44'/coin'/0'/0/0.
+func defaultAddresses(mnemonic, passphrase string) (string, string, error) {
+	masterKey, err := hdwallet.GetMasterKeyByMnemonic(mnemonic, passphrase)
+	if err != nil {
+		return "", "", err
+	}
+	tronKey, err := hdwallet.DerivePrivateKey(masterKey, constant.CoinTron)
+	if err != nil {
+		return "", "", err
+	}
+	ethKey, err := hdwallet.DerivePrivateKey(masterKey, constant.CoinEth)
+	if err != nil {
+		return "", "", err
+	}
+	return hdwallet.DeriveTronAddress(tronKey).String(), hdwallet.DeriveEthAddress(ethKey).Hex(), nil
+}
@@ -0,0 +1,104 @@
+package service
+
+import (
+	"context"
+
+	"github.com/shopspring/decimal"
+	"wallet/internal/apiResponse"
+	"wallet/internal/message"
+	"wallet/internal/requests"
+	"wallet/pkg/constant"
+	"wallet/pkg/hdwallet/tron"
+	"wallet/pkg/signer"
+	"wallet/pkg/tools/blockchain"
+	"wallet/pkg/zlogger"
+)
+
+// stakeOperation is a Stake 2.0 transaction of sender, it returns the
+// transaction id once broadcast.
+type stakeOperation func(ctx context.Context, sender signer.Signer) (string, error)
+
+// sunAmount converts a positive TRX amount to sun, ok is false when it is
+// not positive or does not fit in an int64.
+func sunAmount(amount decimal.Decimal) (int64, bool) {
+	sun := blockchain.ToWei(amount, constant.TrxDecimals)
+	if sun.Sign() <= 0 || !sun.IsInt64() {
+		return 0, false
+	}
+	return sun.Int64(), true
+}
+
+// runStakeOperation loads the Tron key of source and runs op with it.
+func runStakeOperation(ctx context.Context, name string, source requests.KeySource, params requests.DerivationParams, op stakeOperation) *apiResponse.Response {
+	path, err := derivationPath(constant.CoinTron, params)
+	if err != nil {
+		return apiResponse.Fail(message.InvalidDerivationPath)
+	}
+
+	sender, err := loadSigner(ctx, source, path)
+	if err != nil {
+		return apiResponse.Fail(keyFailMessage(err))
+	}
+	defer sender.Close()
+
+	txId, err := op(ctx, sender)
+	if err != nil {
+		zlogger.Errorf("[%s] %s fail %v", name, tron.SignerAddress(sender), err)
+		return apiResponse.Fail(message.Fail)
+	}
+	return apiResponse.Success(TronTransferResult{TxID: txId}, message.Success)
+}
+
+func FreezeBalance(ctx context.Context, req requests.StakeRequest) *apiResponse.Response {
+	resource, err := tron.ParseResource(req.Resource)
+	amount, ok := sunAmount(req.Amount)
+	if err != nil || !ok {
+		return apiResponse.Fail(message.ParamError)
+	}
+	return runStakeOperation(ctx, "FreezeBalance", req.KeySource, req.DerivationParams, func(ctx context.Context, sender signer.Signer) (string, error) {
+		return tron.FreezeBalance(ctx, sender, resource, amount)
+	})
+}
+
+func UnfreezeBalance(ctx context.Context, req requests.StakeRequest) *apiResponse.Response {
+	resource, err := tron.ParseResource(req.Resource)
+	amount, ok := sunAmount(req.Amount)
+	if err != nil || !ok {
+		return apiResponse.Fail(message.ParamError)
+	}
+	return runStakeOperation(ctx, "UnfreezeBalance", req.KeySource, req.DerivationParams, func(ctx context.Context, sender signer.Signer) (string, error) {
+		return tron.UnfreezeBalance(ctx, sender, resource, amount)
+	})
+}
+
+func WithdrawExpireUnfreeze(ctx context.Context, req requests.WithdrawUnfreezeRequest) *apiResponse.Response {
+	return runStakeOperation(ctx, "WithdrawExpireUnfreeze", req.KeySource, req.DerivationParams, tron.WithdrawExpireUnfreeze)
+}
+
+func DelegateResource(ctx context.Context, req requests.DelegateResourceRequest) *apiResponse.Response {
+	if result, _ := validateAddressFmt(req.ReceiverAddress, constant.NetworkTron); !result {
+		return apiResponse.Fail(message.InvalidAddressFormat)
+	}
+	resource, err := tron.ParseResource(req.Resource)
+	amount, ok := sunAmount(req.Amount)
+	if err != nil || !ok {
+		return apiResponse.Fail(message.ParamError)
+	}
+	return runStakeOperation(ctx, "DelegateResource", req.KeySource, req.DerivationParams, func(ctx context.Context, sender signer.Signer) (string, error) {
+		return tron.DelegateResource(ctx, sender, req.ReceiverAddress, resource, amount, req.LockPeriod)
+	})
+}
+
+func UnDelegateResource(ctx context.Context, req requests.DelegateResourceRequest) *apiResponse.Response {
+	if result, _ := validateAddressFmt(req.ReceiverAddress, constant.NetworkTron); !result {
+		return apiResponse.Fail(message.InvalidAddressFormat)
+	}
+	resource, err := tron.ParseResource(req.Resource)
+	amount, ok := sunAmount(req.Amount)
+	if err != nil || !ok {
+		return apiResponse.Fail(message.ParamError)
+	}
+	return runStakeOperation(ctx, "UnDelegateResource", req.KeySource, req.DerivationParams, func(ctx context.Context, sender signer.Signer) (string, error) {
+		return tron.UnDelegateResource(ctx, sender, req.ReceiverAddress, resource, amount)
+	})
+}
@@ -0,0 +1,380 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/shopspring/decimal"
+	"wallet/internal/apiResponse"
+	"wallet/internal/message"
+	"wallet/internal/requests"
+	"wallet/pkg/constant"
+	"wallet/pkg/hdwallet/eth"
+	"wallet/pkg/hdwallet/tron"
+	"wallet/pkg/signer"
+	"wallet/pkg/tools/blockchain"
+	"wallet/pkg/zlogger"
+)
+
+type TokenBalance struct {
+	Symbol   string `json:"symbol"`
+	Contract string `json:"contract"`
+	Decimals uint8  `json:"decimals"`
+	Balance  string `json:"balance"`
+}
+
+// ethToken resolves symbol against the ERC-20 registry, reading the token
+// decimals on first use.
+func ethToken(ctx context.Context, symbol string) (*eth.TokenInfo, *apiResponse.Response) {
+	token, err := eth.Client.Token(ctx, symbol)
+	if err != nil {
+		if errors.Is(err, eth.ErrUnknownToken) {
+			return nil, apiResponse.Fail(message.UnknownToken)
+		}
+		zlogger.Errorf("[ethToken] load token %s error %v", symbol, err)
+		return nil, apiResponse.Fail(message.Fail)
+	}
+	return token, nil
+}
+
+func GetEthTokenBalance(ctx context.Context, symbol, address string) *apiResponse.Response {
+	result, _ := validateAddressFmt(address, constant.NetworkEth)
+	if !result {
+		return apiResponse.Fail(message.InvalidAddressFormat)
+	}
+	token, fail := ethToken(ctx, symbol)
+	if fail != nil {
+		return fail
+	}
+	balance, err := eth.Client.GetTokenBalance(ctx, token, common.HexToAddress(address))
+	if err != nil {
+		zlogger.Errorf("[GetEthTokenBalance] get %s balance error %v", token.Symbol, err)
+		return apiResponse.Fail(message.Fail)
+	}
+	return apiResponse.Success(TokenBalance{
+		Symbol:   token.Symbol,
+		Contract: token.Contract.Hex(),
+		Decimals: token.Decimals,
+		Balance:  blockchain.ToDecimal(balance, int(token.Decimals)).String(),
+	}, message.Success)
+}
+
+func TransferEthToken(ctx context.Context, req requests.TransferTokenRequest) *apiResponse.Response {
+	result, _ := validateAddressFmt(req.ReceiverAddress, constant.NetworkEth)
+	if !result {
+		return apiResponse.Fail(message.InvalidAddressFormat)
+	}
+	if !req.Amount.IsPositive() {
+		return apiResponse.Fail(message.ParamError)
+	}
+	token, fail := ethToken(ctx, req.Symbol)
+	if fail != nil {
+		return fail
+	}
+	path, err := derivationPath(constant.CoinEth, req.DerivationParams)
+	if err != nil {
+		return apiResponse.Fail(message.InvalidDerivationPath)
+	}
+
+	sender, err := loadSigner(ctx, req.KeySource, path)
+	if err != nil {
+		return apiResponse.Fail(keyFailMessage(err))
+	}
+	defer sender.Close()
+
+	transfer, fail := transferEthToken(ctx, sender, token, req.ReceiverAddress, req.Amount)
+	if fail != nil {
+		return fail
+	}
+	return apiResponse.Success(transfer, message.Success)
+}
+
+// transferEthToken checks the sender holds amount of token and sends it,
+// the response is set when it did not.
+func transferEthToken(ctx context.Context, sender signer.Signer, token *eth.TokenInfo, receiverAddress string, amount decimal.Decimal) (*eth.TransferResult, *apiResponse.Response) {
+	senderAddr := eth.SignerAddress(sender)
+	if strings.EqualFold(senderAddr.Hex(), receiverAddress) {
+		return nil, apiResponse.Fail(message.SelfTransferNotAllow)
+	}
+
+	value := blockchain.ToWei(amount, int(token.Decimals))
+	balance, err := eth.Client.GetTokenBalance(ctx, token, senderAddr)
+	if err != nil {
+		zlogger.Errorf("[transferEthToken] get %s balance error %v", token.Symbol, err)
+		return nil, apiResponse.Fail(message.Fail)
+	}
+	if balance.Cmp(value) < 0 {
+		zlogger.Warnf("Available Balance sender %s, %s balance %v, transfer amount %v", senderAddr.Hex(), token.Symbol, balance, value)
+		return nil, apiResponse.Fail(message.LowBalance)
+	}
+
+	fee, err := eth.Client.SuggestGasFee(ctx)
+	if err != nil {
+		zlogger.Errorf("[transferEthToken] suggest gas fee error %v", err)
+		return nil, apiResponse.Fail(message.Fail)
+	}
+	transfer, err := eth.Client.TransferToken(ctx, sender, token, receiverAddress, value, fee)
+	if err != nil {
+		if errors.Is(err, eth.ErrInsufficientBalance) {
+			return nil, apiResponse.Fail(message.LowBalance)
+		}
+		zlogger.Errorf("[transferEthToken] transfer %s fail %v", token.Symbol, err)
+		return nil, apiResponse.Fail(message.Fail)
+	}
+	return transfer, nil
+}
+
+// tronToken resolves symbol against the TRC-20 registry, reading the token
+// decimals on first use.
+func tronToken(symbol string) (*tron.TokenInfo, *apiResponse.Response) {
+	token, err := tron.Token(symbol)
+	if err != nil {
+		if errors.Is(err, tron.ErrUnknownToken) {
+			return nil, apiResponse.Fail(message.UnknownToken)
+		}
+		zlogger.Errorf("[tronToken] load token %s error %v", symbol, err)
+		return nil, apiResponse.Fail(message.Fail)
+	}
+	return token, nil
+}
+
+func GetTronTokenBalance(ctx context.Context, symbol, address string) *apiResponse.Response {
+	result, _ := validateAddressFmt(address, constant.NetworkTron)
+	if !result {
+		return apiResponse.Fail(message.InvalidAddressFormat)
+	}
+	token, fail := tronToken(symbol)
+	if fail != nil {
+		return fail
+	}
+	balance, err := tron.GetTrc20Balance(address, token.Contract)
+	if err != nil {
+		zlogger.Errorf("[GetTronTokenBalance] get %s balance error %v", token.Symbol, err)
+		return apiResponse.Fail(message.Fail)
+	}
+	return apiResponse.Success(TokenBalance{
+		Symbol:   token.Symbol,
+		Contract: token.Contract,
+		Decimals: uint8(token.Decimals),
+		Balance:  blockchain.ToDecimal(balance, token.Decimals).String(),
+	}, message.Success)
+}
+
+func TransferTronToken(ctx context.Context, req requests.TransferTokenRequest) *apiResponse.Response {
+	result, _ := validateAddressFmt(req.ReceiverAddress, constant.NetworkTron)
+	if !result {
+		return apiResponse.Fail(message.InvalidAddressFormat)
+	}
+	if !req.Amount.IsPositive() {
+		return apiResponse.Fail(message.ParamError)
+	}
+	token, fail := tronToken(req.Symbol)
+	if fail != nil {
+		return fail
+	}
+	path, err := derivationPath(constant.CoinTron, req.DerivationParams)
+	if err != nil {
+		return apiResponse.Fail(message.InvalidDerivationPath)
+	}
+
+	sender, err := loadSigner(ctx, req.KeySource, path)
+	if err != nil {
+		return apiResponse.Fail(keyFailMessage(err))
+	}
+	defer sender.Close()
+
+	transfer, fail := transferTronToken(ctx, sender, token, req.ReceiverAddress, req.Amount)
+	if fail != nil {
+		return fail
+	}
+	return apiResponse.Success(transfer, message.Success)
+}
+
+// transferTronToken checks the sender holds amount of token and sends it,
+// the response is set when it did not.
+func transferTronToken(ctx context.Context, sender signer.Signer, token *tron.TokenInfo, receiverAddress string, amount decimal.Decimal) (*TronTransferResult, *apiResponse.Response) {
+	senderAddr := tron.SignerAddress(sender)
+	if senderAddr == receiverAddress {
+		return nil, apiResponse.Fail(message.SelfTransferNotAllow)
+	}
+
+	available, err := tron.GetTrc20Balance(senderAddr, token.Contract)
+	if err != nil {
+		zlogger.Errorf("[transferTronToken] get %s balance error %v", token.Symbol, err)
+		return nil, apiResponse.Fail(message.Fail)
+	}
+	if blockchain.ToDecimal(available, token.Decimals).Cmp(amount) < 0 {
+		zlogger.Warnf("Available Balance sender %s, %s balance %v, transfer amount %v", senderAddr, token.Symbol, available, amount)
+		return nil, apiResponse.Fail(message.LowBalance)
+	}
+
+	// The dry run catches transfers that would run out of energy or revert
+	// before anything reaches the chain.
+	value := blockchain.ToWei(amount, token.Decimals)
+	fee, err := tron.EstimateTrc20TransferFee(senderAddr, token, receiverAddress, value)
+	if err != nil {
+		zlogger.Errorf("[transferTronToken] estimate %s fee error %v", token.Symbol, err)
+		return nil, apiResponse.Fail(message.FeeEstimationFailed)
+	}
+	account, err := tron.GetAccount(senderAddr)
+	if err != nil {
+		zlogger.Errorf("[transferTronToken] get account error %v", err)
+		return nil, apiResponse.Fail(message.Fail)
+	}
+	if account == nil || account.Balance < fee.Fee {
+		zlogger.Warnf("Available Balance sender %s, trx balance %v, fee %v", senderAddr, account.GetBalance(), fee.Fee)
+		return nil, apiResponse.Fail(message.LowBalance)
+	}
+
+	txId, err := tron.TransferTrc20(ctx, sender, token, receiverAddress, value, fee.FeeLimit)
+	if err != nil {
+		zlogger.Errorf("[transferTronToken] transfer %s fail %v", token.Symbol, err)
+		return nil, apiResponse.Fail(message.Fail)
+	}
+	return &TronTransferResult{TxID: txId, TronFee: fee}, nil
+}
+
+type Trc10Balance struct {
+	Asset   *tron.Trc10Asset `json:"asset"`
+	Balance string           `json:"balance"`
+}
+
+func trc10Asset(assetID string) (*tron.Trc10Asset, *apiResponse.Response) {
+	if !regexp.MustCompile(constant.Trc10AssetFmt).MatchString(assetID) {
+		return nil, apiResponse.Fail(message.ParamError)
+	}
+	asset, err := tron.GetTrc10Asset(assetID)
+	if err != nil {
+		if errors.Is(err, tron.ErrUnknownAsset) {
+			return nil, apiResponse.Fail(message.UnknownAsset)
+		}
+		zlogger.Errorf("[trc10Asset] get asset %s error %v", assetID, err)
+		return nil, apiResponse.Fail(message.Fail)
+	}
+	return asset, nil
+}
+
+func GetTrc10Asset(ctx context.Context, assetID string) *apiResponse.Response {
+	asset, fail := trc10Asset(assetID)
+	if fail != nil {
+		return fail
+	}
+	return apiResponse.Success(asset, message.Success)
+}
+
+func GetTrc10Balance(ctx context.Context, assetID, address string) *apiResponse.Response {
+	result, _ := validateAddressFmt(address, constant.NetworkTron)
+	if !result {
+		return apiResponse.Fail(message.InvalidAddressFormat)
+	}
+	asset, fail := trc10Asset(assetID)
+	if fail != nil {
+		return fail
+	}
+	balance, err := tron.GetTrc10Balance(address, asset.ID)
+	if err != nil {
+		zlogger.Errorf("[GetTrc10Balance] get %s balance error %v", asset.ID, err)
+		return apiResponse.Fail(message.Fail)
+	}
+	return apiResponse.Success(Trc10Balance{
+		Asset:   asset,
+		Balance: blockchain.ToDecimal(balance, asset.Precision).String(),
+	}, message.Success)
+}
+
+// TransferTrc10 sends a TRC-10 asset, the sender must hold the amount and
+// enough TRX for the bandwidth and activation fees.
+func TransferTrc10(ctx context.Context, req requests.TransferTrc10Request) *apiResponse.Response {
+	result, _ := validateAddressFmt(req.ReceiverAddress, constant.NetworkTron)
+	if !result {
+		return apiResponse.Fail(message.InvalidAddressFormat)
+	}
+	asset, fail := trc10Asset(req.AssetID)
+	if fail != nil {
+		return fail
+	}
+	amount := blockchain.ToWei(req.Amount, asset.Precision)
+	if amount.Sign() <= 0 || !amount.IsInt64() {
+		return apiResponse.Fail(message.ParamError)
+	}
+	path, err := derivationPath(constant.CoinTron, req.DerivationParams)
+	if err != nil {
+		return apiResponse.Fail(message.InvalidDerivationPath)
+	}
+
+	sender, err := loadSigner(ctx, req.KeySource, path)
+	if err != nil {
+		return apiResponse.Fail(keyFailMessage(err))
+	}
+	defer sender.Close()
+
+	senderAddr := tron.SignerAddress(sender)
+	if senderAddr == req.ReceiverAddress {
+		return apiResponse.Fail(message.SelfTransferNotAllow)
+	}
+
+	account, err := tron.GetAccount(senderAddr)
+	if err != nil {
+		zlogger.Errorf("[TransferTrc10] get account error %v", err)
+		return apiResponse.Fail(message.Fail)
+	}
+	if account == nil || account.AssetV2[asset.ID] < amount.Int64() {
+		return apiResponse.Fail(message.LowBalance)
+	}
+	fee, err := tron.EstimateTrc10TransferFee(senderAddr, req.ReceiverAddress)
+	if err != nil {
+		zlogger.Errorf("[TransferTrc10] estimate fee error %v", err)
+		return apiResponse.Fail(message.Fail)
+	}
+	if account.Balance < fee.Fee {
+		zlogger.Warnf("Available Balance sender %s, trx balance %v, fee %v", senderAddr, account.Balance, fee.Fee)
+		return apiResponse.Fail(message.LowBalance)
+	}
+
+	txId, err := tron.TransferTrc10(ctx, sender, asset.ID, req.ReceiverAddress, amount.Int64())
+	if err != nil {
+		zlogger.Errorf("[TransferTrc10] transfer %s fail %v", asset.ID, err)
+		return apiResponse.Fail(message.Fail)
+	}
+	return apiResponse.Success(TronTransferResult{TxID: txId, TronFee: fee}, message.Success)
+}
+
+func GetTronResources(ctx context.Context, address string) *apiResponse.Response {
+	result, _ := validateAddressFmt(address, constant.NetworkTron)
+	if !result {
+		return apiResponse.Fail(message.InvalidAddressFormat)
+	}
+	resources, err := tron.GetResources(address)
+	if err != nil {
+		zlogger.Errorf("[GetTronResources] get account resource error %v", err)
+		return apiResponse.Fail(message.Fail)
+	}
+	return apiResponse.Success(resources, message.Success)
+}
+
+// EstimateTronTokenFee dry runs a TRC-20 transfer and reports the energy,
+// bandwidth and TRX it would burn.
+func EstimateTronTokenFee(ctx context.Context, req requests.EstimateTronTokenFeeRequest) *apiResponse.Response {
+	for _, address := range []string{req.SenderAddress, req.ReceiverAddress} {
+		if result, _ := validateAddressFmt(address, constant.NetworkTron); !result {
+			return apiResponse.Fail(message.InvalidAddressFormat)
+		}
+	}
+	amount, err := decimal.NewFromString(req.Amount)
+	if err != nil || !amount.IsPositive() {
+		return apiResponse.Fail(message.ParamError)
+	}
+	token, fail := tronToken(req.Symbol)
+	if fail != nil {
+		return fail
+	}
+	fee, err := tron.EstimateTrc20TransferFee(req.SenderAddress, token, req.ReceiverAddress, blockchain.ToWei(amount, token.Decimals))
+	if err != nil {
+		zlogger.Errorf("[EstimateTronTokenFee] estimate %s fee error %v", token.Symbol, err)
+		return apiResponse.Fail(message.FeeEstimationFailed)
+	}
+	return apiResponse.Success(fee, message.Success)
+}
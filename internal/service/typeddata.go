@@ -0,0 +1,99 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+	"wallet/internal/apiResponse"
+	"wallet/internal/message"
+	"wallet/internal/requests"
+	"wallet/pkg/constant"
+	"wallet/pkg/hdwallet/eth"
+	"wallet/pkg/signer"
+	"wallet/pkg/zlogger"
+)
+
+type TypedDataHashResult struct {
+	DomainSeparator string `json:"domain_separator"`
+	MessageHash     string `json:"message_hash"`
+	Digest          string `json:"digest"`
+}
+
+type SignTypedDataResult struct {
+	TypedDataHashResult
+	Address   string `json:"address"`
+	Signature string `json:"signature"`
+}
+
+// HashTypedData shows the EIP-712 hashes of a document without signing, so
+// callers can check what a signature would commit to.
+func HashTypedData(ctx context.Context, req requests.TypedDataHashRequest) *apiResponse.Response {
+	hash, failMessage := hashTypedData(ctx, req.TypedData)
+	if hash == nil {
+		return apiResponse.Fail(failMessage)
+	}
+	return apiResponse.Success(typedDataHashResult(hash), message.Success)
+}
+
+func SignTypedData(ctx context.Context, req requests.SignTypedDataRequest) *apiResponse.Response {
+	path, err := derivationPath(constant.CoinEth, req.DerivationParams)
+	if err != nil {
+		return apiResponse.Fail(message.InvalidDerivationPath)
+	}
+	hash, failMessage := hashTypedData(ctx, req.TypedData)
+	if hash == nil {
+		return apiResponse.Fail(failMessage)
+	}
+
+	sender, err := loadSigner(ctx, req.KeySource, path)
+	if err != nil {
+		return apiResponse.Fail(keyFailMessage(err))
+	}
+	defer sender.Close()
+
+	signature, err := signer.SignMessageHash(ctx, sender, hash.Digest)
+	if err != nil {
+		zlogger.Errorf("[SignTypedData] sign typed data error %v", err)
+		return apiResponse.Fail(message.Fail)
+	}
+	return apiResponse.Success(SignTypedDataResult{
+		TypedDataHashResult: typedDataHashResult(hash),
+		Address:             eth.SignerAddress(sender).Hex(),
+		Signature:           hexutil.Encode(signature),
+	}, message.Success)
+}
+
+// hashTypedData hashes raw against the chain id of the connected ETH node,
+// it returns the response message when the document is rejected.
+func hashTypedData(ctx context.Context, raw json.RawMessage) (*signer.TypedDataHash, string) {
+	var data apitypes.TypedData
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return nil, message.InvalidTypedData
+	}
+
+	chainID, err := eth.Client.GetChainID(ctx)
+	if err != nil {
+		zlogger.Errorf("[hashTypedData] get chain id error %v", err)
+		return nil, message.Fail
+	}
+
+	hash, err := signer.HashTypedData(data, chainID)
+	if err != nil {
+		if errors.Is(err, signer.ErrChainIDMismatch) || errors.Is(err, signer.ErrMissingChainID) {
+			return nil, message.ChainIDMismatch
+		}
+		return nil, message.InvalidTypedData
+	}
+	return hash, ""
+}
+
+func typedDataHashResult(hash *signer.TypedDataHash) TypedDataHashResult {
+	return TypedDataHashResult{
+		DomainSeparator: hexutil.Encode(hash.DomainSeparator),
+		MessageHash:     hexutil.Encode(hash.MessageHash),
+		Digest:          hexutil.Encode(hash.Digest),
+	}
+}
@@ -2,13 +2,16 @@ package service
 
 import (
 	"context"
+	"crypto/ecdsa"
+	"errors"
 	"fmt"
-	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
 	"regexp"
+	"strings"
 	"wallet/internal/apiResponse"
 	"wallet/internal/message"
 	"wallet/internal/requests"
-	"wallet/pkg/common/config"
 	"wallet/pkg/constant"
 	"wallet/pkg/hdwallet"
 	"wallet/pkg/hdwallet/eth"
@@ -17,93 +20,149 @@ import (
 	"wallet/pkg/zlogger"
 )
 
-func GetWalletAddress(c context.Context, req requests.GetAddressRequest) *apiResponse.Response {
-	var err error
-	masterKey, err := hdwallet.GetMasterKeyByMnemonic(req.Mnemonic)
+// TronTransferResult is returned by the Tron transfers, ETH transfers
+// return eth.TransferResult.
+type TronTransferResult struct {
+	TxID string `json:"tx_id"`
+	// TronFee is the most the transfer was expected to burn.
+	TronFee *tron.TransferFee `json:"tron_fee,omitempty"`
+}
+
+type EthTransactionResult struct {
+	TxID              string `json:"tx_id"`
+	Pending           bool   `json:"pending"`
+	Status            uint64 `json:"status"`
+	BlockNumber       string `json:"block_number,omitempty"`
+	GasLimit          uint64 `json:"gas_limit"`
+	GasUsed           uint64 `json:"gas_used"`
+	EffectiveGasPrice string `json:"effective_gas_price,omitempty"`
+}
+
+func NewMnemonic(ctx context.Context, req requests.NewMnemonicRequest) *apiResponse.Response {
+	if req.Words == 0 {
+		req.Words = hdwallet.DefaultMnemonicWords
+	}
+	if req.Language == "" {
+		req.Language = hdwallet.LanguageEnglish
+	}
+	mnemonic, err := hdwallet.NewMnemonic(req.Words, req.Language)
+	if err != nil {
+		return apiResponse.Fail(message.MnemonicCreateFail)
+	}
+	return apiResponse.Success(mnemonic, message.Success)
+}
+
+func GetWalletAddress(ctx context.Context, req requests.GetAddressRequest) *apiResponse.Response {
+	coin, err := coinType(req.Network)
 	if err != nil {
-		return apiResponse.Fail(message.InvalidMnemonic)
+		return apiResponse.Fail(message.ParamError)
+	}
+	path, err := derivationPath(coin, req.DerivationParams)
+	if err != nil {
+		return apiResponse.Fail(message.InvalidDerivationPath)
 	}
 
+	sender, err := loadSigner(ctx, req.KeySource, path)
+	if err != nil {
+		return apiResponse.Fail(keyFailMessage(err))
+	}
+	defer sender.Close()
+
 	switch req.Network {
 	case constant.NetworkTron:
-		privateKey, err := hdwallet.DerivePrivateKey(masterKey, constant.CoinTron)
-		if err != nil {
-			return apiResponse.Fail(message.Fail)
-		}
-		tronAddr := hdwallet.DeriveTronAddress(privateKey)
-		return apiResponse.Success(tronAddr.String(), message.Success)
+		return apiResponse.Success(tron.SignerAddress(sender), message.Success)
 	case constant.NetworkEth:
-		privateKey, err := hdwallet.DerivePrivateKey(masterKey, constant.CoinEth)
-		if err != nil {
-			return apiResponse.Fail(message.Fail)
-		}
-		ethAddr := hdwallet.DeriveEthAddress(privateKey)
-		return apiResponse.Success(ethAddr.Hex(), message.Success)
+		return apiResponse.Success(eth.SignerAddress(sender).Hex(), message.Success)
 	default:
 		return apiResponse.Fail(message.ParamError)
 	}
 }
 
 func TransferUSDT(ctx context.Context, req requests.TransferUSDTRequest) *apiResponse.Response {
-	var err error
-	masterKey, err := hdwallet.GetMasterKeyByMnemonic(req.Mnemonic)
+	coin, err := coinType(req.Network)
 	if err != nil {
-		return apiResponse.Fail(message.Fail)
+		return apiResponse.Fail(message.ParamError)
 	}
+	if result, _ := validateAddressFmt(req.ReceiverAddress, req.Network); !result {
+		return apiResponse.Fail(message.InvalidAddressFormat)
+	}
+	if !req.Amount.IsPositive() {
+		return apiResponse.Fail(message.ParamError)
+	}
+	path, err := derivationPath(coin, req.DerivationParams)
+	if err != nil {
+		return apiResponse.Fail(message.InvalidDerivationPath)
+	}
+
+	sender, err := loadSigner(ctx, req.KeySource, path)
+	if err != nil {
+		return apiResponse.Fail(keyFailMessage(err))
+	}
+	defer sender.Close()
 
 	switch req.Network {
 	case constant.NetworkTron:
-		privateKey, err := hdwallet.DerivePrivateKey(masterKey, constant.CoinTron)
-		if err != nil {
-			return apiResponse.Fail(message.InvalidMnemonic)
-		}
-		senderAddr := hdwallet.DeriveTronAddress(privateKey)
-		if senderAddr.String() == req.ReceiverAddress {
-			return apiResponse.Fail(message.SelfTransferNotAllow)
+		token, fail := tronToken(tron.USDTSymbol)
+		if fail != nil {
+			return fail
 		}
-
-		availableUSDT, err := tron.GetTrc20Balance(senderAddr.String(), config.Config.Blockchain.TronUSDTContract)
-		availableUsdt := blockchain.ToDecimal(availableUSDT, constant.UsdtDecimals)
-		if err != nil {
-			zlogger.Errorf("[TransferUSDT] GetTrc20Balance error %v", err)
-			return apiResponse.Fail(message.Fail)
+		result, fail := transferTronToken(ctx, sender, token, req.ReceiverAddress, req.Amount)
+		if fail != nil {
+			return fail
 		}
-
-		if availableUsdt.Cmp(req.Amount) < 0 {
-			zlogger.Warnf("Available Balance sender %s, balance %v, transfer amount %v", senderAddr.String(), availableUsdt, req.Amount)
-			return apiResponse.Fail(message.LowBalance)
-		}
-
-		txId, err := tron.TransferTrc20(senderAddr.String(), req.ReceiverAddress, crypto.FromECDSA(privateKey), blockchain.ToWei(req.Amount, constant.UsdtDecimals))
-		if err != nil {
-			zlogger.Errorf("[TransferUSDT] transfer fail %v", err)
-			return apiResponse.Fail(message.Fail)
-		}
-		return apiResponse.Success(txId, message.Success)
+		// The Tron branch has always answered with the bare transaction id.
+		return apiResponse.Success(result.TxID, message.Success)
 	case constant.NetworkEth:
-		privateKey, err := hdwallet.DerivePrivateKey(masterKey, constant.CoinEth)
-		if err != nil {
-			return apiResponse.Fail(message.InvalidMnemonic)
+		token, fail := ethToken(ctx, eth.USDTSymbol)
+		if fail != nil {
+			return fail
 		}
-		senderAddr := hdwallet.DeriveEthAddress(privateKey)
-		if senderAddr.String() == req.ReceiverAddress {
-			return apiResponse.Fail(message.SelfTransferNotAllow)
-		}
-		gasPrice, err := eth.Client.SuggestGasPrice(ctx)
-		if err != nil {
-			return apiResponse.Fail(message.Fail)
+		result, fail := transferEthToken(ctx, sender, token, req.ReceiverAddress, req.Amount)
+		if fail != nil {
+			return fail
 		}
-		txId, err := eth.Client.TransferUSDT(ctx, crypto.FromECDSA(privateKey), senderAddr, req.ReceiverAddress, blockchain.ToWei(req.Amount, constant.UsdtDecimals), gasPrice)
-		if err != nil {
-			zlogger.Errorf("[TransferUSDT] transfer fail %v", err)
-			return apiResponse.Fail(message.Fail)
-		}
-		return apiResponse.Success(txId, message.Success)
+		return apiResponse.Success(result, message.Success)
 	default:
 		return apiResponse.Fail(message.ParamError)
 	}
 }
 
+// GetEthTransaction reports the gas limit of a transaction and, once it is
+// mined, the gas it used.
+func GetEthTransaction(ctx context.Context, hash string) *apiResponse.Response {
+	if !regexp.MustCompile(constant.EthTxHashFmt).MatchString(hash) {
+		return apiResponse.Fail(message.ParamError)
+	}
+
+	txHash := common.HexToHash(hash)
+	tx, pending, err := eth.Client.GetTransaction(ctx, txHash)
+	if err != nil {
+		if errors.Is(err, ethereum.NotFound) {
+			return apiResponse.Fail(message.TransactionNotFound)
+		}
+		zlogger.Errorf("[GetEthTransaction] get transaction error %v", err)
+		return apiResponse.Fail(message.Fail)
+	}
+
+	result := EthTransactionResult{TxID: tx.Hash().Hex(), Pending: pending, GasLimit: tx.Gas()}
+	if pending {
+		return apiResponse.Success(result, message.Success)
+	}
+
+	receipt, err := eth.Client.GetTransactionReceipt(ctx, txHash)
+	if err != nil {
+		zlogger.Errorf("[GetEthTransaction] get receipt error %v", err)
+		return apiResponse.Fail(message.Fail)
+	}
+	result.Status = receipt.Status
+	result.BlockNumber = receipt.BlockNumber.String()
+	result.GasUsed = receipt.GasUsed
+	if receipt.EffectiveGasPrice != nil {
+		result.EffectiveGasPrice = receipt.EffectiveGasPrice.String()
+	}
+	return apiResponse.Success(result, message.Success)
+}
+
 func GetTrxBalance(ctx context.Context, address string) *apiResponse.Response {
 	result, _ := validateAddressFmt(address, constant.NetworkTron)
 	if !result {
@@ -114,25 +173,174 @@ func GetTrxBalance(ctx context.Context, address string) *apiResponse.Response {
 		zlogger.Errorf("[GetTrxBalance] get tron balance error %v", err)
 		return apiResponse.Fail(message.Fail)
 	}
-	return apiResponse.Success(blockchain.ToDecimal(account.Balance, constant.UsdtDecimals), message.Success)
+	return apiResponse.Success(blockchain.ToDecimal(account.Balance, constant.TrxDecimals), message.Success)
+}
+
+// TransferTrx sends TRX, the sender must hold the amount plus the bandwidth
+// and activation fees the transfer may burn.
+func TransferTrx(ctx context.Context, req requests.TransferTRXRequest) *apiResponse.Response {
+	result, _ := validateAddressFmt(req.ReceiverAddress, constant.NetworkTron)
+	if !result {
+		return apiResponse.Fail(message.InvalidAddressFormat)
+	}
+	amount := blockchain.ToWei(req.Amount, constant.TrxDecimals)
+	if amount.Sign() <= 0 || !amount.IsInt64() {
+		return apiResponse.Fail(message.ParamError)
+	}
+	path, err := derivationPath(constant.CoinTron, req.DerivationParams)
+	if err != nil {
+		return apiResponse.Fail(message.InvalidDerivationPath)
+	}
+
+	sender, err := loadSigner(ctx, req.KeySource, path)
+	if err != nil {
+		return apiResponse.Fail(keyFailMessage(err))
+	}
+	defer sender.Close()
+
+	senderAddr := tron.SignerAddress(sender)
+	if senderAddr == req.ReceiverAddress {
+		return apiResponse.Fail(message.SelfTransferNotAllow)
+	}
+
+	account, err := tron.GetAccount(senderAddr)
+	if err != nil {
+		zlogger.Errorf("[TransferTrx] get account error %v", err)
+		return apiResponse.Fail(message.Fail)
+	}
+	if account == nil {
+		return apiResponse.Fail(message.LowBalance)
+	}
+	fee, err := tron.EstimateTrxTransferFee(senderAddr, req.ReceiverAddress)
+	if err != nil {
+		zlogger.Errorf("[TransferTrx] estimate fee error %v", err)
+		return apiResponse.Fail(message.Fail)
+	}
+	if account.Balance < amount.Int64()+fee.Fee {
+		zlogger.Warnf("Available Balance sender %s, trx balance %v, transfer amount %v, fee %v", senderAddr, account.Balance, amount, fee.Fee)
+		return apiResponse.Fail(message.LowBalance)
+	}
+
+	txId, err := tron.TransferTrx(ctx, sender, req.ReceiverAddress, amount.Int64())
+	if err != nil {
+		zlogger.Errorf("[TransferTrx] transfer fail %v", err)
+		return apiResponse.Fail(message.Fail)
+	}
+	return apiResponse.Success(TronTransferResult{TxID: txId, TronFee: fee}, message.Success)
 }
 
 func GetUsdtBalance(ctx context.Context, address string) *apiResponse.Response {
-	account, err := tron.GetTrc20Balance(address, config.Config.Blockchain.TronUSDTContract)
+	token, fail := tronToken(tron.USDTSymbol)
+	if fail != nil {
+		return fail
+	}
+	account, err := tron.GetTrc20Balance(address, token.Contract)
 	if err != nil {
 		zlogger.Errorf("[GetTrxBalance] get usdt balance error %v", err)
 		return apiResponse.Fail(message.Fail)
 	}
-	return apiResponse.Success(blockchain.ToDecimal(account, constant.UsdtDecimals), message.Success)
+	return apiResponse.Success(blockchain.ToDecimal(account, token.Decimals), message.Success)
+}
+
+func GetEthBalance(ctx context.Context, address string) *apiResponse.Response {
+	result, _ := validateAddressFmt(address, constant.NetworkEth)
+	if !result {
+		return apiResponse.Fail(message.InvalidAddressFormat)
+	}
+	balance, err := eth.Client.GetBalance(ctx, common.HexToAddress(address))
+	if err != nil {
+		zlogger.Errorf("[GetEthBalance] get eth balance error %v", err)
+		return apiResponse.Fail(message.Fail)
+	}
+	return apiResponse.Success(blockchain.ToDecimal(balance, eth.Client.ETHDecimals()), message.Success)
+}
+
+// TransferETH sends ether, the sender must hold the amount plus the most the
+// gas may cost.
+func TransferETH(ctx context.Context, req requests.TransferETHRequest) *apiResponse.Response {
+	result, _ := validateAddressFmt(req.ReceiverAddress, constant.NetworkEth)
+	if !result {
+		return apiResponse.Fail(message.InvalidAddressFormat)
+	}
+	if !req.Amount.IsPositive() {
+		return apiResponse.Fail(message.ParamError)
+	}
+	path, err := derivationPath(constant.CoinEth, req.DerivationParams)
+	if err != nil {
+		return apiResponse.Fail(message.InvalidDerivationPath)
+	}
+
+	sender, err := loadSigner(ctx, req.KeySource, path)
+	if err != nil {
+		return apiResponse.Fail(keyFailMessage(err))
+	}
+	defer sender.Close()
+
+	if strings.EqualFold(eth.SignerAddress(sender).Hex(), req.ReceiverAddress) {
+		return apiResponse.Fail(message.SelfTransferNotAllow)
+	}
+
+	fee, err := eth.Client.SuggestGasFee(ctx)
+	if err != nil {
+		zlogger.Errorf("[TransferETH] suggest gas fee error %v", err)
+		return apiResponse.Fail(message.Fail)
+	}
+	transfer, err := eth.Client.TransferETH(ctx, sender, req.ReceiverAddress, blockchain.ToWei(req.Amount, eth.Client.ETHDecimals()), fee)
+	if err != nil {
+		if errors.Is(err, eth.ErrInsufficientBalance) {
+			return apiResponse.Fail(message.LowBalance)
+		}
+		zlogger.Errorf("[TransferETH] transfer fail %v", err)
+		return apiResponse.Fail(message.Fail)
+	}
+	return apiResponse.Success(transfer, message.Success)
 }
 
 func GetEThUsdtBalance(ctx context.Context, address string) *apiResponse.Response {
-	account, err := eth.Client.GetUSDT(address)
+	token, fail := ethToken(ctx, eth.USDTSymbol)
+	if fail != nil {
+		return fail
+	}
+	account, err := eth.Client.GetTokenBalance(ctx, token, common.HexToAddress(address))
 	if err != nil {
 		zlogger.Errorf("[GetEThUsdtBalance] get usdt balance error %v", err)
 		return apiResponse.Fail(message.Fail)
 	}
-	return apiResponse.Success(blockchain.ToDecimal(account, constant.UsdtDecimals), message.Success)
+	return apiResponse.Success(blockchain.ToDecimal(account, int(token.Decimals)), message.Success)
+}
+
+func coinType(network int) (uint32, error) {
+	switch network {
+	case constant.NetworkTron:
+		return constant.CoinTron, nil
+	case constant.NetworkEth:
+		return constant.CoinEth, nil
+	default:
+		return 0, fmt.Errorf("invalid network")
+	}
+}
+
+// publicKeyAddress formats the address of publicKey on network, network
+// must already be validated by coinType.
+func publicKeyAddress(network int, publicKey *ecdsa.PublicKey) string {
+	if network == constant.NetworkTron {
+		return hdwallet.PublicKeyToTronAddress(publicKey).String()
+	}
+	return hdwallet.PublicKeyToEthAddress(publicKey).Hex()
+}
+
+func derivationPath(coin uint32, params requests.DerivationParams) (hdwallet.DerivationPath, error) {
+	if params.Path != "" {
+		path, err := hdwallet.ParseDerivationPath(params.Path)
+		if err != nil {
+			return nil, err
+		}
+		if err = path.CheckCoinType(coin); err != nil {
+			return nil, err
+		}
+		return path, nil
+	}
+	return hdwallet.NewDerivationPath(coin, params.Account, params.Change, params.AddressIndex), nil
 }
 
 func validateAddressFmt(address string, network int) (bool, error) {
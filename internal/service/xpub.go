@@ -0,0 +1,88 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/tyler-smith/go-bip32"
+	"wallet/internal/apiResponse"
+	"wallet/internal/message"
+	"wallet/internal/requests"
+	"wallet/pkg/constant"
+	"wallet/pkg/hdwallet"
+	"wallet/pkg/zlogger"
+)
+
+type XpubResult struct {
+	Xpub string `json:"xpub"`
+	Path string `json:"path"`
+}
+
+// XpubAddress is a child of the account xpub, Path is relative to the account.
+type XpubAddress struct {
+	Path    string `json:"path"`
+	Index   uint32 `json:"index"`
+	Address string `json:"address"`
+}
+
+func ExportXpub(ctx context.Context, req requests.ExportXpubRequest) *apiResponse.Response {
+	masterKey, err := loadMasterKey(ctx, req.WalletID, req.Mnemonic, req.Passphrase)
+	if err != nil {
+		return apiResponse.Fail(keyFailMessage(err))
+	}
+
+	coin, err := coinType(req.Network)
+	if err != nil {
+		return apiResponse.Fail(message.ParamError)
+	}
+
+	xpub, err := hdwallet.ExportAccountXpub(masterKey, coin, req.Account)
+	if errors.Is(err, hdwallet.ErrIndexOutOfRange) {
+		return apiResponse.Fail(message.ParamError)
+	}
+	if err != nil {
+		zlogger.Errorf("[ExportXpub] export xpub error %v", err)
+		return apiResponse.Fail(message.Fail)
+	}
+	path := hdwallet.NewDerivationPath(coin, req.Account, 0, 0)[:3]
+	return apiResponse.Success(XpubResult{Xpub: xpub, Path: path.String()}, message.Success)
+}
+
+func GetXpubAddress(ctx context.Context, req requests.XpubAddressRequest) *apiResponse.Response {
+	accountKey, err := hdwallet.ParseXpub(req.Xpub)
+	if err != nil {
+		return apiResponse.Fail(message.InvalidXpub)
+	}
+
+	if req.Count == 0 {
+		req.Count = 1
+	}
+	if uint64(req.AddressIndex)+uint64(req.Count) > uint64(bip32.FirstHardenedChild) {
+		return apiResponse.Fail(message.ParamError)
+	}
+	addresses := make([]XpubAddress, 0, req.Count)
+	for i := uint32(0); i < req.Count; i++ {
+		index := req.AddressIndex + i
+		publicKey, err := hdwallet.DerivePublicKeyFromXpub(accountKey, req.Change, index)
+		if errors.Is(err, hdwallet.ErrIndexOutOfRange) {
+			return apiResponse.Fail(message.ParamError)
+		}
+		if err != nil {
+			zlogger.Errorf("[GetXpubAddress] derive index %d error %v", index, err)
+			return apiResponse.Fail(message.Fail)
+		}
+
+		address := XpubAddress{Path: fmt.Sprintf("%d/%d", req.Change, index), Index: index}
+		switch req.Network {
+		case constant.NetworkTron:
+			address.Address = hdwallet.PublicKeyToTronAddress(publicKey).String()
+		case constant.NetworkEth:
+			address.Address = hdwallet.PublicKeyToEthAddress(publicKey).Hex()
+		default:
+			return apiResponse.Fail(message.ParamError)
+		}
+		addresses = append(addresses, address)
+	}
+	return apiResponse.Success(addresses, message.Success)
+}
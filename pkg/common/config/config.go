@@ -1,5 +1,50 @@
 package config
 
+// SignerConfig is the connection to the remote signing host. It is TLS
+// verified against RemoteCACert and authenticated with RemoteToken, a
+// client certificate, or both.
+type SignerConfig struct {
+	// RemoteAddress of the signing host, empty disables remote signing.
+	RemoteAddress    string `yaml:"remoteAddress"`
+	RemoteCACert     string `yaml:"remoteCACert"`
+	RemoteClientCert string `yaml:"remoteClientCert"`
+	RemoteClientKey  string `yaml:"remoteClientKey"`
+	RemoteToken      string `yaml:"remoteToken"`
+	// Insecure allows a plaintext, unauthenticated connection. Development
+	// only, anyone reaching the signer can sign with its keys.
+	Insecure bool `yaml:"insecure"`
+}
+
+// KmsConfig selects the key-encryption-key provider that wraps the per
+// wallet data keys, see pkg/kms.
+type KmsConfig struct {
+	Provider string `yaml:"provider"` // file, env or pkcs11
+	KeyFile  string `yaml:"keyFile"`
+	EnvVar   string `yaml:"envVar"`
+	Pkcs11   struct {
+		Module     string `yaml:"module"`
+		TokenLabel string `yaml:"tokenLabel"`
+		Pin        string `yaml:"pin"`
+		KeyLabel   string `yaml:"keyLabel"`
+	} `yaml:"pkcs11"`
+}
+
+// EthTokenConfig registers an ERC-20 token, its decimals are read from the
+// contract. GasLimit is used when estimating a transfer fails, 100000 when
+// unset.
+type EthTokenConfig struct {
+	Symbol   string `yaml:"symbol"`
+	Contract string `yaml:"contract"`
+	GasLimit uint64 `yaml:"gasLimit"`
+}
+
+// TronTokenConfig registers a TRC-20 token by its base58 contract, its
+// decimals are read from the contract.
+type TronTokenConfig struct {
+	Symbol   string `yaml:"symbol"`
+	Contract string `yaml:"contract"`
+}
+
 var Config struct {
 	App struct {
 		Timezone string `yaml:"timezone"`
@@ -41,13 +86,59 @@ var Config struct {
 	Jwt struct {
 		Key string `yaml:"key"`
 	} `yaml:"jwt"`
+	Admin struct {
+		// Token guards the /admin endpoints through the X-Admin-Token
+		// header, empty disables them.
+		Token string `yaml:"token"`
+	} `yaml:"admin"`
+	Custody struct {
+		// DisableMnemonicInBody rejects requests that carry a raw mnemonic,
+		// private key or keystore, only wallet_id references to stored
+		// wallets and remote key ids are accepted.
+		DisableMnemonicInBody bool `yaml:"disableMnemonicInBody"`
+	} `yaml:"custody"`
+	Kms       KmsConfig    `yaml:"kms"`
+	Signer    SignerConfig `yaml:"signer"`
+	Discovery struct {
+		// GapLimit is the run of unused addresses that ends a chain, 20
+		// when unset.
+		GapLimit uint32 `yaml:"gapLimit"`
+		// MaxAccounts caps the BIP44 accounts scanned per chain, 10 when unset.
+		MaxAccounts uint32 `yaml:"maxAccounts"`
+	} `yaml:"discovery"`
 	Blockchain struct {
-		MnemonicPhrase   string `yaml:"mnemonicPhrase"`
-		TronAlchemy      string `yaml:"tronAlchemy"`
-		TronUSDTContract string `yaml:"tronUsdtContract"`
-		TronGrpc         string `yaml:"tronGrpc"`
-		TronGasFee       string `yaml:"tronGasFee"`
-		EthAlchemy       string `yaml:"ethAlchemy"`
-		EthUSDTContract  string `yaml:"ethUsdtContract"`
+		MnemonicPhrase   string            `yaml:"mnemonicPhrase"`
+		TronAlchemy      string            `yaml:"tronAlchemy"`
+		TronUSDTContract string            `yaml:"tronUsdtContract"`
+		TronGrpc         string            `yaml:"tronGrpc"`
+		TronGasFee       string            `yaml:"tronGasFee"`
+		TronTokens       []TronTokenConfig `yaml:"tronTokens"`
+		TronFee          struct {
+			// EnergyMultiplier is the margin on estimated energy the fee
+			// limit allows, 1.2 when unset.
+			EnergyMultiplier float64 `yaml:"energyMultiplier"`
+			// FeeLimitCap is the highest fee limit in sun ever sent,
+			// 100000000 when unset.
+			FeeLimitCap int64 `yaml:"feeLimitCap"`
+		} `yaml:"tronFee"`
+		EthAlchemy      string `yaml:"ethAlchemy"`
+		EthUSDTContract string `yaml:"ethUsdtContract"`
+		EthFee          struct {
+			// Legacy sends type 0 transactions with a single gas price, for
+			// chains without EIP-1559.
+			Legacy bool `yaml:"legacy"`
+			// FeeHistoryBlocks and RewardPercentile select the eth_feeHistory
+			// window the priority fee is taken from, 10 blocks at the 50th
+			// percentile when unset.
+			FeeHistoryBlocks uint64  `yaml:"feeHistoryBlocks"`
+			RewardPercentile float64 `yaml:"rewardPercentile"`
+		} `yaml:"ethFee"`
+		EthGas struct {
+			// Multiplier is the safety margin on eth_estimateGas, 1.2 when unset.
+			Multiplier float64 `yaml:"multiplier"`
+			// Cap is the highest gas limit ever sent, 1000000 when unset.
+			Cap uint64 `yaml:"cap"`
+		} `yaml:"ethGas"`
+		EthTokens []EthTokenConfig `yaml:"ethTokens"`
 	} `yaml:"blockchain"`
 }
@@ -17,10 +17,13 @@ const (
 
 const (
 	UsdtDecimals = 6
+	TrxDecimals  = 6
 	ethDecimals  = 18
 )
 
 const (
 	EthAddressFmt  = "^0x[0-9a-fA-F]{40}$"
 	TronAddressFmt = "^[Tt][0-9a-zA-Z]{33}$"
+	EthTxHashFmt   = "^0x[0-9a-fA-F]{64}$"
+	Trc10AssetFmt  = "^1[0-9]{6}$"
 )
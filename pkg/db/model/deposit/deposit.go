@@ -0,0 +1,104 @@
+package deposit
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// mysqlDuplicateEntry is ER_DUP_ENTRY, a unique index rejected the row.
+const mysqlDuplicateEntry = 1062
+
+// ErrUserAllocated reports that a user got an address of the wallet and
+// network from a concurrent allocation. Allocations are locked per account,
+// the user binding is unique per network, so only the index catches it.
+var ErrUserAllocated = errors.New("user already has a deposit address")
+
+// AddressIndex is the next unallocated external address index of one wallet
+// account on one network. Allocators lock the row, so an index is never
+// handed out twice.
+type AddressIndex struct {
+	ID        uint64    `gorm:"column:id;primaryKey;autoIncrement"`
+	WalletID  string    `gorm:"column:wallet_id;type:varchar(64);not null;uniqueIndex:idx_deposit_index_chain"`
+	Network   int       `gorm:"column:network;not null;uniqueIndex:idx_deposit_index_chain"`
+	Account   uint32    `gorm:"column:account;not null;uniqueIndex:idx_deposit_index_chain"`
+	NextIndex uint32    `gorm:"column:next_index;not null;default:0"`
+	UpdatedAt time.Time `gorm:"column:updated_at"`
+}
+
+func (AddressIndex) TableName() string {
+	return "deposit_address_index"
+}
+
+// AddressInfo binds the address at m/44'/coin'/account'/0/address_index of a
+// wallet to an external user, a user has at most one address per wallet and
+// network.
+type AddressInfo struct {
+	ID           uint64    `gorm:"column:id;primaryKey;autoIncrement" json:"-"`
+	WalletID     string    `gorm:"column:wallet_id;type:varchar(64);not null;uniqueIndex:idx_deposit_path;uniqueIndex:idx_deposit_user" json:"wallet_id"`
+	Network      int       `gorm:"column:network;not null;uniqueIndex:idx_deposit_path;uniqueIndex:idx_deposit_user" json:"network"`
+	Account      uint32    `gorm:"column:account;not null;uniqueIndex:idx_deposit_path" json:"account"`
+	AddressIndex uint32    `gorm:"column:address_index;not null;uniqueIndex:idx_deposit_path" json:"address_index"`
+	Address      string    `gorm:"column:address;type:varchar(64);not null;uniqueIndex" json:"address"`
+	UserID       string    `gorm:"column:user_id;type:varchar(64);not null;uniqueIndex:idx_deposit_user" json:"user_id"`
+	CreatedAt    time.Time `gorm:"column:created_at" json:"created_at"`
+}
+
+func (AddressInfo) TableName() string {
+	return "deposit_address"
+}
+
+func NewDeposit(db *gorm.DB) *Deposit {
+	return &Deposit{
+		DB: db,
+	}
+}
+
+type Deposit struct {
+	DB *gorm.DB
+}
+
+// FindByUsers returns the addresses already bound to userIDs.
+func (d *Deposit) FindByUsers(ctx context.Context, walletID string, network int, userIDs []string) ([]*AddressInfo, error) {
+	var list []*AddressInfo
+	err := d.DB.WithContext(ctx).Where("wallet_id = ? AND network = ? AND user_id IN ?", walletID, network, userIDs).Find(&list).Error
+	return list, err
+}
+
+// LockNextIndex returns the next index of the account and locks its row
+// until the surrounding transaction ends, d.DB must be a transaction.
+func (d *Deposit) LockNextIndex(ctx context.Context, walletID string, network int, account uint32) (*AddressIndex, error) {
+	db := d.DB.WithContext(ctx)
+	err := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&AddressIndex{WalletID: walletID, Network: network, Account: account}).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var index AddressIndex
+	err = db.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("wallet_id = ? AND network = ? AND account = ?", walletID, network, account).
+		Take(&index).Error
+	if err != nil {
+		return nil, err
+	}
+	return &index, nil
+}
+
+func (d *Deposit) UpdateNextIndex(ctx context.Context, id uint64, nextIndex uint32) error {
+	return d.DB.WithContext(ctx).Model(&AddressIndex{}).Where("id = ?", id).Update("next_index", nextIndex).Error
+}
+
+// CreateAddresses binds list, ErrUserAllocated when one of the users was
+// bound meanwhile.
+func (d *Deposit) CreateAddresses(ctx context.Context, list []*AddressInfo) error {
+	err := d.DB.WithContext(ctx).CreateInBatches(list, 500).Error
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) && mysqlErr.Number == mysqlDuplicateEntry {
+		return ErrUserAllocated
+	}
+	return err
+}
@@ -0,0 +1,152 @@
+package multisig
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	StatusPending   = "pending"
+	StatusBroadcast = "broadcast"
+	StatusExpired   = "expired"
+)
+
+// Transaction is a Tron transaction of a multi-signature account collecting
+// signatures. RawTransaction is the protobuf encoded transaction with the
+// signatures gathered so far, it is broadcast once their weight reaches the
+// threshold of PermissionID.
+type Transaction struct {
+	ID             uint64    `gorm:"column:id;primaryKey;autoIncrement" json:"-"`
+	TxID           string    `gorm:"column:tx_id;type:varchar(64);not null;uniqueIndex" json:"tx_id"`
+	OwnerAddress   string    `gorm:"column:owner_address;type:varchar(64);not null;index" json:"owner_address"`
+	PermissionID   int32     `gorm:"column:permission_id;not null" json:"permission_id"`
+	Kind           string    `gorm:"column:kind;type:varchar(32);not null" json:"kind"`
+	RawTransaction []byte    `gorm:"column:raw_transaction;type:blob;not null" json:"-"`
+	Status         string    `gorm:"column:status;type:varchar(16);not null;index" json:"status"`
+	CreatedBy      string    `gorm:"column:created_by;type:varchar(64)" json:"created_by,omitempty"`
+	Expiration     time.Time `gorm:"column:expiration;not null" json:"expiration"`
+	CreatedAt      time.Time `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt      time.Time `gorm:"column:updated_at" json:"updated_at"`
+}
+
+func (Transaction) TableName() string {
+	return "tron_multisig_transaction"
+}
+
+// Expire marks a pending transaction whose signing window closed before now
+// as expired, it reports whether it did.
+func (t *Transaction) Expire(now time.Time) bool {
+	if t.Status != StatusPending || !now.After(t.Expiration) {
+		return false
+	}
+	t.Status = StatusExpired
+	return true
+}
+
+func NewMultisig(db *gorm.DB) *Multisig {
+	return &Multisig{
+		DB: db,
+	}
+}
+
+type Multisig struct {
+	DB *gorm.DB
+}
+
+func (m *Multisig) Create(ctx context.Context, tx *Transaction) error {
+	return m.DB.WithContext(ctx).Create(tx).Error
+}
+
+func (m *Multisig) FindByTxID(ctx context.Context, txID string) (*Transaction, error) {
+	var tx Transaction
+	err := m.DB.WithContext(ctx).Where("tx_id = ?", txID).Take(&tx).Error
+	if err != nil {
+		return nil, err
+	}
+	return &tx, nil
+}
+
+// LockByTxID loads the transaction and locks its row until the surrounding
+// transaction ends, so concurrent signatures are never lost. m.DB must be a
+// transaction.
+func (m *Multisig) LockByTxID(ctx context.Context, txID string) (*Transaction, error) {
+	var tx Transaction
+	err := m.DB.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).Where("tx_id = ?", txID).Take(&tx).Error
+	if err != nil {
+		return nil, err
+	}
+	return &tx, nil
+}
+
+func (m *Multisig) Update(ctx context.Context, id uint64, rawTransaction []byte, status string) error {
+	return m.DB.WithContext(ctx).Model(&Transaction{}).Where("id = ?", id).
+		Updates(map[string]interface{}{"raw_transaction": rawTransaction, "status": status}).Error
+}
+
+// UpdateStatus moves the transaction from status from to status to. It
+// changes nothing when a concurrent request moved it first.
+func (m *Multisig) UpdateStatus(ctx context.Context, id uint64, from, to string) error {
+	return m.DB.WithContext(ctx).Model(&Transaction{}).Where("id = ? AND status = ?", id, from).Update("status", to).Error
+}
+
+const StatusExecuted = "executed"
+
+// SafeTransaction is a proposed transaction of an Ethereum Safe collecting
+// owner signatures. Transaction and Signatures are JSON, the Safe
+// transaction as the owners sign it and the signatures gathered so far.
+type SafeTransaction struct {
+	ID          uint64    `gorm:"column:id;primaryKey;autoIncrement" json:"-"`
+	SafeTxHash  string    `gorm:"column:safe_tx_hash;type:varchar(66);not null;uniqueIndex" json:"safe_tx_hash"`
+	SafeAddress string    `gorm:"column:safe_address;type:varchar(42);not null;index" json:"safe_address"`
+	ChainID     uint64    `gorm:"column:chain_id;not null" json:"chain_id"`
+	Kind        string    `gorm:"column:kind;type:varchar(32);not null" json:"kind"`
+	Transaction string    `gorm:"column:transaction;type:text;not null" json:"-"`
+	Signatures  string    `gorm:"column:signatures;type:text;not null" json:"-"`
+	Status      string    `gorm:"column:status;type:varchar(16);not null;index" json:"status"`
+	ExecTxID    string    `gorm:"column:exec_tx_id;type:varchar(66)" json:"exec_tx_id,omitempty"`
+	CreatedBy   string    `gorm:"column:created_by;type:varchar(64)" json:"created_by,omitempty"`
+	CreatedAt   time.Time `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt   time.Time `gorm:"column:updated_at" json:"updated_at"`
+}
+
+func (SafeTransaction) TableName() string {
+	return "eth_safe_transaction"
+}
+
+func (m *Multisig) CreateSafe(ctx context.Context, tx *SafeTransaction) error {
+	return m.DB.WithContext(ctx).Create(tx).Error
+}
+
+func (m *Multisig) FindSafeByHash(ctx context.Context, hash string) (*SafeTransaction, error) {
+	var tx SafeTransaction
+	err := m.DB.WithContext(ctx).Where("safe_tx_hash = ?", hash).Take(&tx).Error
+	if err != nil {
+		return nil, err
+	}
+	return &tx, nil
+}
+
+// LockSafeByHash is LockByTxID for Safe transactions.
+func (m *Multisig) LockSafeByHash(ctx context.Context, hash string) (*SafeTransaction, error) {
+	var tx SafeTransaction
+	err := m.DB.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).Where("safe_tx_hash = ?", hash).Take(&tx).Error
+	if err != nil {
+		return nil, err
+	}
+	return &tx, nil
+}
+
+func (m *Multisig) UpdateSafe(ctx context.Context, id uint64, signatures, status, execTxID string) error {
+	return m.DB.WithContext(ctx).Model(&SafeTransaction{}).Where("id = ?", id).
+		Updates(map[string]interface{}{"signatures": signatures, "status": status, "exec_tx_id": execTxID}).Error
+}
+
+// UpdateSafeStatus is UpdateStatus for Safe transactions, recording the
+// transaction that executed it.
+func (m *Multisig) UpdateSafeStatus(ctx context.Context, id uint64, from, to, execTxID string) error {
+	return m.DB.WithContext(ctx).Model(&SafeTransaction{}).Where("id = ? AND status = ?", id, from).
+		Updates(map[string]interface{}{"status": to, "exec_tx_id": execTxID}).Error
+}
@@ -0,0 +1,27 @@
+package multisig
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTransactionExpire(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name       string
+		status     string
+		expiration time.Time
+		expired    bool
+		want       string
+	}{
+		{"open", StatusPending, now.Add(time.Minute), false, StatusPending},
+		{"closed", StatusPending, now.Add(-time.Minute), true, StatusExpired},
+		{"broadcast", StatusBroadcast, now.Add(-time.Minute), false, StatusBroadcast},
+	}
+	for _, tt := range tests {
+		tx := &Transaction{Status: tt.status, Expiration: tt.expiration}
+		if expired := tx.Expire(now); expired != tt.expired || tx.Status != tt.want {
+			t.Errorf("%s: expired %v status %s, want %v %s", tt.name, expired, tx.Status, tt.expired, tt.want)
+		}
+	}
+}
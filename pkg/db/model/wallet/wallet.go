@@ -1,9 +1,48 @@
 package wallet
 
 import (
+	"context"
+	"time"
+
 	"gorm.io/gorm"
 )
 
+const (
+	SourceGenerated = "generated"
+	SourceImported  = "imported"
+	SourceRecovered = "recovered"
+)
+
+const (
+	// KindHD wallets store a BIP39 seed and derive keys by path.
+	KindHD = "hd"
+	// KindPrivateKey wallets store a single raw secp256k1 key.
+	KindPrivateKey = "private_key"
+)
+
+// WalletInfo is a custodial wallet, its secret is only ever stored encrypted.
+// EncryptedSeed holds the BIP39 seed (already combined with the passphrase)
+// of KindHD wallets and the raw key of KindPrivateKey wallets. It is sealed
+// with a per wallet data key which is stored wrapped by the KEK named KekID.
+type WalletInfo struct {
+	ID             uint64    `gorm:"column:id;primaryKey;autoIncrement" json:"-"`
+	WalletID       string    `gorm:"column:wallet_id;type:varchar(64);uniqueIndex;not null" json:"wallet_id"`
+	Label          string    `gorm:"column:label;type:varchar(128)" json:"label"`
+	Kind           string    `gorm:"column:kind;type:varchar(16);not null;default:'hd'" json:"kind"`
+	EncryptedSeed  []byte    `gorm:"column:encrypted_seed;type:varbinary(255);not null" json:"-"`
+	KekID          string    `gorm:"column:kek_id;type:varchar(64);not null;default:'';index" json:"-"`
+	WrappedDataKey []byte    `gorm:"column:wrapped_data_key;type:varbinary(255)" json:"-"`
+	Source         string    `gorm:"column:source;type:varchar(16)" json:"source"`
+	Language       string    `gorm:"column:language;type:varchar(32)" json:"language"`
+	CreatedBy      string    `gorm:"column:created_by;type:varchar(64)" json:"created_by"`
+	CreatedAt      time.Time `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt      time.Time `gorm:"column:updated_at" json:"updated_at"`
+}
+
+func (WalletInfo) TableName() string {
+	return "wallet"
+}
+
 func NewWallet(db *gorm.DB) *Wallet {
 	return &Wallet{
 		DB: db,
@@ -13,3 +52,45 @@ func NewWallet(db *gorm.DB) *Wallet {
 type Wallet struct {
 	DB *gorm.DB
 }
+
+func (w *Wallet) Create(ctx context.Context, info *WalletInfo) error {
+	return w.DB.WithContext(ctx).Create(info).Error
+}
+
+func (w *Wallet) Take(ctx context.Context, walletID string) (*WalletInfo, error) {
+	var info WalletInfo
+	err := w.DB.WithContext(ctx).Where("wallet_id = ?", walletID).Take(&info).Error
+	if err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+func (w *Wallet) Find(ctx context.Context, offset, limit int) ([]*WalletInfo, int64, error) {
+	var (
+		list  []*WalletInfo
+		total int64
+	)
+	query := w.DB.WithContext(ctx).Model(&WalletInfo{})
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	err := query.Order("id DESC").Offset(offset).Limit(limit).Find(&list).Error
+	return list, total, err
+}
+
+func (w *Wallet) UpdateEncryption(ctx context.Context, id uint64, kekID string, wrappedDataKey, encryptedSeed []byte) error {
+	return w.DB.WithContext(ctx).Model(&WalletInfo{}).Where("id = ?", id).Updates(map[string]any{
+		"kek_id":           kekID,
+		"wrapped_data_key": wrappedDataKey,
+		"encrypted_seed":   encryptedSeed,
+	}).Error
+}
+
+// FindNotWrappedBy pages through wallets whose data key is not wrapped by
+// kekID, ordered by id so callers can resume after the last row they saw.
+func (w *Wallet) FindNotWrappedBy(ctx context.Context, kekID string, afterID uint64, limit int) ([]*WalletInfo, error) {
+	var list []*WalletInfo
+	err := w.DB.WithContext(ctx).Where("kek_id <> ? AND id > ?", kekID, afterID).Order("id ASC").Limit(limit).Find(&list).Error
+	return list, err
+}
@@ -0,0 +1,87 @@
+package hdwallet
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+
+	"github.com/tyler-smith/go-bip32"
+)
+
+// DefaultGapLimit is the BIP44 run of unused addresses that ends a chain.
+const DefaultGapLimit = 20
+
+var ErrInvalidGapLimit = errors.New("gap limit must be positive")
+
+// UsageChecker reports whether the address of publicKey has any on-chain
+// history on the chain being scanned.
+type UsageChecker func(ctx context.Context, publicKey *ecdsa.PublicKey) (bool, error)
+
+type UsedAddress struct {
+	Path      DerivationPath
+	Account   uint32
+	Change    uint32
+	Index     uint32
+	PublicKey *ecdsa.PublicKey
+}
+
+// DiscoverAddresses walks the accounts of coinType as BIP44 account
+// discovery does: the external and change chain of each account are scanned
+// until gapLimit consecutive unused addresses, and the scan stops at the
+// first account without any used address or after maxAccounts accounts.
+// Keys are derived from the account xpub, so no private key is touched.
+func DiscoverAddresses(ctx context.Context, masterKey *bip32.Key, coinType, gapLimit, maxAccounts uint32, used UsageChecker) ([]UsedAddress, error) {
+	if gapLimit == 0 {
+		return nil, ErrInvalidGapLimit
+	}
+
+	var found []UsedAddress
+	for account := uint32(0); account < maxAccounts; account++ {
+		accountKey, err := DeriveAccountKey(masterKey, coinType, account)
+		if err != nil {
+			return nil, err
+		}
+		accountPublicKey := accountKey.PublicKey()
+
+		before := len(found)
+		for change := uint32(0); change <= 1; change++ {
+			addresses, err := scanChain(ctx, accountPublicKey, change, gapLimit, used)
+			if err != nil {
+				return nil, err
+			}
+			for _, address := range addresses {
+				address.Path = NewDerivationPath(coinType, account, change, address.Index)
+				address.Account = account
+				found = append(found, address)
+			}
+		}
+		if len(found) == before {
+			break
+		}
+	}
+	return found, nil
+}
+
+func scanChain(ctx context.Context, accountKey *bip32.Key, change, gapLimit uint32, used UsageChecker) ([]UsedAddress, error) {
+	var found []UsedAddress
+	for index, gap := uint32(0), uint32(0); gap < gapLimit; index++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		publicKey, err := DerivePublicKeyFromXpub(accountKey, change, index)
+		if err != nil {
+			return nil, err
+		}
+		ok, err := used(ctx, publicKey)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			gap++
+			continue
+		}
+		gap = 0
+		found = append(found, UsedAddress{Change: change, Index: index, PublicKey: publicKey})
+	}
+	return found, nil
+}
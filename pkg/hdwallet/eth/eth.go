@@ -2,7 +2,6 @@ package eth
 
 import (
 	"context"
-	"crypto/ecdsa"
 	"errors"
 	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
@@ -10,18 +9,21 @@ import (
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/ethclient"
-	"golang.org/x/crypto/sha3"
 	"math"
 	"math/big"
 	"wallet/pkg/common/config"
+	"wallet/pkg/signer"
 	"wallet/pkg/zlogger"
 )
 
 type Geth interface {
 	GetETH(ctx context.Context, address string) (*big.Float, error)
 	GetUSDT(address string) (*big.Int, error)
+	GetBalance(ctx context.Context, address common.Address) (*big.Int, error)
+	GetNonce(ctx context.Context, address common.Address) (uint64, error)
 	SuggestGasPrice(ctx context.Context) (*big.Int, error)
-	EstimateGasLimit(ctx context.Context, address string, data []byte) (uint64, error)
+	SuggestGasFee(ctx context.Context) (*GasFee, error)
+	EstimateGasLimit(ctx context.Context, from, to common.Address, value *big.Int, data []byte) (uint64, error)
 	GetTransaction(ctx context.Context, hash common.Hash) (*types.Transaction, bool, error)
 	GetTransactionInfo(ctx context.Context, hash common.Hash) (*types.Receipt, *types.Transaction, bool, error)
 	GetTransactionReceipt(ctx context.Context, hash common.Hash) (*types.Receipt, error)
@@ -30,20 +32,37 @@ type Geth interface {
 	GetChainID(ctx context.Context) (*big.Int, error)
 	TransferETH(
 		ctx context.Context,
-		senderPrivateKey []byte,
-		senderAddress common.Address,
+		sender signer.Signer,
 		receiverPublicKey string,
 		amount *big.Int,
-		gasPrice *big.Int,
-	) (string, error)
+		fee *GasFee,
+	) (*TransferResult, error)
 
 	TransferUSDT(ctx context.Context,
-		senderPrivateKey []byte,
-		senderAddress common.Address,
+		sender signer.Signer,
 		receiverPublicKey string,
 		amount *big.Int,
-		gasPrice *big.Int,
-	) (string, error)
+		fee *GasFee,
+	) (*TransferResult, error)
+	TransferToken(ctx context.Context,
+		sender signer.Signer,
+		token *TokenInfo,
+		receiverPublicKey string,
+		amount *big.Int,
+		fee *GasFee,
+	) (*TransferResult, error)
+	Token(ctx context.Context, key string) (*TokenInfo, error)
+	Tokens() []TokenInfo
+	GetTokenBalance(ctx context.Context, token *TokenInfo, address common.Address) (*big.Int, error)
+	GetSafeInfo(ctx context.Context, safe common.Address) (*SafeInfo, error)
+	IsSafeOwner(ctx context.Context, safe, owner common.Address) (bool, error)
+	ExecSafeTransaction(ctx context.Context,
+		sender signer.Signer,
+		safe common.Address,
+		tx *SafeTransaction,
+		signatures []SafeSignature,
+		fee *GasFee,
+	) (*TransferResult, error)
 	ETHDecimals() int
 	USDTDecimals() int
 	LeftPadBytesLength() int
@@ -52,8 +71,27 @@ type Geth interface {
 	TransactionNotFoundMsg() string
 }
 
+// Backend is the part of ethclient.Client the wallet uses, tests plug in a
+// simulated chain instead.
+type Backend interface {
+	ethereum.ChainReader
+	ethereum.ChainStateReader
+	ethereum.ContractCaller
+	ethereum.GasEstimator
+	ethereum.GasPricer
+	ethereum.GasPricer1559
+	ethereum.FeeHistoryReader
+	ethereum.LogFilterer
+	ethereum.PendingStateReader
+	ethereum.PendingContractCaller
+	ethereum.TransactionReader
+	ethereum.TransactionSender
+	ethereum.ChainIDReader
+}
+
 type geth struct {
-	client *ethclient.Client
+	client Backend
+	tokens *tokenRegistry
 }
 
 const (
@@ -62,12 +100,17 @@ const (
 	leftPadBytesLength  = 32
 	erc20GasLimit       = 100000
 	ethGasLimit         = 21000
+	safeExecGasLimit    = 300000
 	transactionNotFound = "not found"
 )
 
 var (
-	ErrFailToParse = errors.New("fail to parse big float from string")
-	ErrCastToECDSA = errors.New("fail to cast ECDSA")
+	ErrFailToParse   = errors.New("fail to parse big float from string")
+	ErrCastToECDSA   = errors.New("fail to cast ECDSA")
+	ErrInvalidAmount = errors.New("amount must be positive")
+	// ErrInsufficientBalance is returned when the sender cannot pay the
+	// amount plus the most the gas may cost.
+	ErrInsufficientBalance = errors.New("balance does not cover amount and gas")
 )
 
 var Client Geth
@@ -78,10 +121,17 @@ func Init() Geth {
 		zlogger.Errorf("connect eth blockchain error %v", err)
 		panic(err)
 	}
-	Client = &geth{client: client}
+	Client = NewGeth(client)
 	return Client
 }
 
+func NewGeth(backend Backend) Geth {
+	return &geth{
+		client: backend,
+		tokens: newTokenRegistry(config.Config.Blockchain.EthTokens, config.Config.Blockchain.EthUSDTContract),
+	}
+}
+
 func (g geth) GetETH(ctx context.Context, address string) (*big.Float, error) {
 	account := common.HexToAddress(address)
 
@@ -114,6 +164,16 @@ func (g geth) GetUSDT(address string) (*big.Int, error) {
 	return weiBalance, nil
 }
 
+// GetBalance returns the wei balance of address at the latest block.
+func (g geth) GetBalance(ctx context.Context, address common.Address) (*big.Int, error) {
+	return g.client.BalanceAt(ctx, address, nil)
+}
+
+// GetNonce returns the count of transactions sent from address.
+func (g geth) GetNonce(ctx context.Context, address common.Address) (uint64, error) {
+	return g.client.NonceAt(ctx, address, nil)
+}
+
 func (g geth) SuggestGasPrice(ctx context.Context) (*big.Int, error) {
 	gasPrice, err := g.client.SuggestGasPrice(ctx)
 	if err != nil {
@@ -123,12 +183,12 @@ func (g geth) SuggestGasPrice(ctx context.Context) (*big.Int, error) {
 	return gasPrice, nil
 }
 
-func (g geth) EstimateGasLimit(ctx context.Context, address string, data []byte) (uint64, error) {
-	a := common.HexToAddress(address)
-
+func (g geth) EstimateGasLimit(ctx context.Context, from, to common.Address, value *big.Int, data []byte) (uint64, error) {
 	gasLimit, err := g.client.EstimateGas(ctx, ethereum.CallMsg{
-		To:   &a,
-		Data: data,
+		From:  from,
+		To:    &to,
+		Value: value,
+		Data:  data,
 	})
 	if err != nil {
 		return 0, err
@@ -139,105 +199,114 @@ func (g geth) EstimateGasLimit(ctx context.Context, address string, data []byte)
 
 func (g geth) TransferETH(
 	ctx context.Context,
-	senderPrivateKey []byte,
-	senderAddress common.Address,
+	sender signer.Signer,
 	receiverPublicKey string,
 	amount *big.Int,
-	gasPrice *big.Int,
-) (string, error) {
-	nonce, err := g.client.PendingNonceAt(ctx, senderAddress)
-	if err != nil {
-		return "", err
+	fee *GasFee,
+) (*TransferResult, error) {
+	if amount.Sign() <= 0 {
+		return nil, ErrInvalidAmount
 	}
 
-	chainID, err := g.GetChainID(ctx)
+	from := SignerAddress(sender)
+	receiver := common.HexToAddress(receiverPublicKey)
+	balance, err := g.GetBalance(ctx, from)
 	if err != nil {
-		return "", err
+		return nil, err
+	}
+	// A node refuses to estimate a transfer of more than the balance.
+	if balance.Cmp(amount) < 0 {
+		return nil, ErrInsufficientBalance
 	}
+	gas, err := g.estimateGas(ctx, from, receiver, amount, nil, ethGasLimit)
+	if err != nil {
+		return nil, err
+	}
+	if balance.Cmp(new(big.Int).Add(amount, fee.MaxCost(gas.GasLimit))) < 0 {
+		return nil, ErrInsufficientBalance
+	}
+	return g.sendTransaction(ctx, sender, receiver, amount, nil, gas, fee)
+}
 
-	userAddressPk := common.HexToAddress(receiverPublicKey)
+func (g geth) TransferUSDT(
+	ctx context.Context,
+	sender signer.Signer,
+	receiverPublicKey string,
+	amount *big.Int,
+	fee *GasFee,
+) (*TransferResult, error) {
+	token, err := g.Token(ctx, USDTSymbol)
+	if err != nil {
+		return nil, err
+	}
+	return g.TransferToken(ctx, sender, token, receiverPublicKey, amount, fee)
+}
 
-	tx := types.NewTx(&types.LegacyTx{
-		Nonce:    nonce,
-		To:       &userAddressPk,
-		Value:    amount,
-		Gas:      erc20GasLimit,
-		GasPrice: gasPrice,
-		Data:     nil,
-	})
-	signedTx, err := types.SignTx(tx, types.NewEIP155Signer(chainID), crypto.ToECDSAUnsafe(senderPrivateKey))
+// TransferToken sends amount, in the smallest unit of token, to receiver.
+// The sender must hold the most the gas may cost in ether.
+func (g geth) TransferToken(
+	ctx context.Context,
+	sender signer.Signer,
+	token *TokenInfo,
+	receiverPublicKey string,
+	amount *big.Int,
+	fee *GasFee,
+) (*TransferResult, error) {
+	if amount.Sign() <= 0 {
+		return nil, ErrInvalidAmount
+	}
 
+	from := SignerAddress(sender)
+	data := erc20TransferData(common.HexToAddress(receiverPublicKey), amount)
+	gas, err := g.estimateGas(ctx, from, token.Contract, big.NewInt(0), data, token.GasLimit)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	err = g.client.SendTransaction(ctx, signedTx)
+	// The token moves but the gas is paid in ether.
+	balance, err := g.GetBalance(ctx, from)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
-
-	return signedTx.Hash().Hex(), nil
+	if balance.Cmp(fee.MaxCost(gas.GasLimit)) < 0 {
+		return nil, ErrInsufficientBalance
+	}
+	return g.sendTransaction(ctx, sender, token.Contract, big.NewInt(0), data, gas, fee)
 }
 
-func (g geth) TransferUSDT(
+// sendTransaction signs and broadcasts a transaction at the pending nonce
+// of sender.
+func (g geth) sendTransaction(
 	ctx context.Context,
-	senderPrivateKey []byte,
-	senderAddress common.Address,
-	receiverPublicKey string,
-	amount *big.Int,
-	gasPrice *big.Int,
-) (string, error) {
-	if amount.Cmp(big.NewInt(0)) > 0 {
-		nonce, err := g.client.PendingNonceAt(ctx, senderAddress)
-		if err != nil {
-			return "", err
-		}
-
-		receiverAddress := common.HexToAddress(receiverPublicKey)
-
-		hash := sha3.NewLegacyKeccak256()
-		hash.Write([]byte("transfer(address,uint256)"))
-		methodID := hash.Sum(nil)[:4]
-
-		paddedAddress := common.LeftPadBytes(receiverAddress.Bytes(), leftPadBytesLength)
-		paddedAmount := common.LeftPadBytes(amount.Bytes(), leftPadBytesLength)
-
-		var data []byte
-		data = append(data, methodID...)
-		data = append(data, paddedAddress...)
-		data = append(data, paddedAmount...)
-
-		chainID, err := g.GetChainID(ctx)
-		if err != nil {
-			return "", err
-		}
-
-		contract := common.HexToAddress(config.Config.Blockchain.EthUSDTContract)
-
-		tx := types.NewTx(&types.LegacyTx{
-			Nonce:    nonce,
-			To:       &contract,
-			Value:    big.NewInt(0),
-			Gas:      erc20GasLimit,
-			GasPrice: gasPrice,
-			Data:     data,
-		})
-
-		signedTx, err := types.SignTx(tx, types.NewEIP155Signer(chainID), crypto.ToECDSAUnsafe(senderPrivateKey))
-		if err != nil {
-			return "", err
-		}
-
-		err = g.client.SendTransaction(ctx, signedTx)
-		if err != nil {
-			return "", err
-		}
-
-		return signedTx.Hash().Hex(), nil
+	sender signer.Signer,
+	to common.Address,
+	value *big.Int,
+	data []byte,
+	gas *GasEstimate,
+	fee *GasFee,
+) (*TransferResult, error) {
+	nonce, err := g.client.PendingNonceAt(ctx, SignerAddress(sender))
+	if err != nil {
+		return nil, err
 	}
 
-	return "", nil
+	chainID, err := g.GetChainID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tx := newTransaction(chainID, nonce, to, value, gas.GasLimit, fee, data)
+	signedTx, err := sender.SignEthTransaction(ctx, tx, chainID)
+	if err != nil {
+		return nil, err
+	}
 
+	err = g.client.SendTransaction(ctx, signedTx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TransferResult{TxID: signedTx.Hash().Hex(), Gas: *gas, Fee: fee}, nil
 }
 
 func (g geth) GetTransaction(ctx context.Context, hash common.Hash) (*types.Transaction, bool, error) {
@@ -296,7 +365,7 @@ func (g geth) GetTransactionByBlockNum(ctx context.Context, blockNum *big.Int) (
 }
 
 func (g geth) GetChainID(ctx context.Context) (*big.Int, error) {
-	chainID, err := g.client.NetworkID(ctx)
+	chainID, err := g.client.ChainID(ctx)
 	if err != nil {
 		return &big.Int{}, err
 	}
@@ -304,14 +373,6 @@ func (g geth) GetChainID(ctx context.Context) (*big.Int, error) {
 	return chainID, nil
 }
 
-// zeroKey zeroes a private key in memory.
-func (g geth) zeroKey(k *ecdsa.PrivateKey) {
-	b := k.D.Bits()
-	for i := range b {
-		b[i] = 0
-	}
-}
-
 func (g geth) ETHDecimals() int {
 	return ethDecimals
 }
@@ -335,3 +396,8 @@ func (g geth) ETHGasLimit() uint64 {
 func (g geth) TransactionNotFoundMsg() string {
 	return transactionNotFound
 }
+
+// SignerAddress returns the Ethereum address of sender.
+func SignerAddress(sender signer.Signer) common.Address {
+	return crypto.PubkeyToAddress(*sender.PublicKey())
+}
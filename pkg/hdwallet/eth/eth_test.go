@@ -0,0 +1,491 @@
+package eth
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient/simulated"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+	"wallet/pkg/common/config"
+	"wallet/pkg/signer"
+)
+
+func newSimulated(t *testing.T) (*simulated.Backend, signer.Signer) {
+	privateKey, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	funds := new(big.Int).Mul(big.NewInt(100), big.NewInt(1e18))
+	backend := simulated.NewBackend(types.GenesisAlloc{
+		crypto.PubkeyToAddress(privateKey.PublicKey): {Balance: funds},
+	})
+	t.Cleanup(func() { backend.Close() })
+	return backend, signer.NewLocalSigner(privateKey)
+}
+
+func TestTransferETHFeeModes(t *testing.T) {
+	ctx := context.Background()
+	receiver := common.HexToAddress("0x3535353535353535353535353535353535353535")
+
+	for _, legacy := range []bool{false, true} {
+		config.Config.Blockchain.EthFee.Legacy = legacy
+		backend, sender := newSimulated(t)
+		g := NewGeth(backend.Client())
+
+		fee, err := g.SuggestGasFee(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fee.IsDynamic() == legacy {
+			t.Fatalf("legacy %v: got fee %+v", legacy, fee)
+		}
+
+		result, err := g.TransferETH(ctx, sender, receiver.Hex(), big.NewInt(1000), fee)
+		if err != nil {
+			t.Fatal(err)
+		}
+		backend.Commit()
+		if result.Gas.Estimated != ethGasLimit || result.Gas.GasLimit != ethGasLimit {
+			t.Errorf("legacy %v: plain transfer gas %+v", legacy, result.Gas)
+		}
+
+		tx, _, err := g.GetTransaction(ctx, common.HexToHash(result.TxID))
+		if err != nil {
+			t.Fatal(err)
+		}
+		wantType := uint8(types.DynamicFeeTxType)
+		if legacy {
+			wantType = types.LegacyTxType
+		}
+		if tx.Type() != wantType {
+			t.Errorf("legacy %v: tx type %d", legacy, tx.Type())
+		}
+		receipt, err := g.GetTransactionReceipt(ctx, tx.Hash())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if receipt.Status != types.ReceiptStatusSuccessful {
+			t.Errorf("legacy %v: transfer failed", legacy)
+		}
+		balance, err := g.GetBalance(ctx, receiver)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if balance.Cmp(big.NewInt(1000)) != 0 {
+			t.Errorf("legacy %v: receiver balance %s", legacy, balance)
+		}
+	}
+	config.Config.Blockchain.EthFee.Legacy = false
+}
+
+func TestTransferETHBalanceIncludesGas(t *testing.T) {
+	ctx := context.Background()
+	backend, sender := newSimulated(t)
+	g := NewGeth(backend.Client())
+	receiver := common.HexToAddress("0x3535353535353535353535353535353535353535")
+
+	fee, err := g.SuggestGasFee(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	balance, err := g.GetBalance(ctx, SignerAddress(sender))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The whole balance leaves nothing for gas.
+	if _, err = g.TransferETH(ctx, sender, receiver.Hex(), balance, fee); err != ErrInsufficientBalance {
+		t.Errorf("expected ErrInsufficientBalance, got %v", err)
+	}
+	amount := new(big.Int).Sub(balance, fee.MaxCost(ethGasLimit))
+	if _, err = g.TransferETH(ctx, sender, receiver.Hex(), amount, fee); err != nil {
+		t.Errorf("balance minus max gas cost: %v", err)
+	}
+}
+
+func TestTransferTokenNeedsGas(t *testing.T) {
+	ctx := context.Background()
+	privateKey, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The stub contract returns 1 to any call, a successful transfer(), but
+	// the sender holds no ether to pay the gas with.
+	contract := common.HexToAddress("0x00000000000000000000000000000000000000e2")
+	backend := simulated.NewBackend(types.GenesisAlloc{
+		contract: {Code: common.FromHex("0x600160005260206000f3"), Balance: big.NewInt(0)},
+	})
+	t.Cleanup(func() { backend.Close() })
+	g := NewGeth(backend.Client())
+
+	fee, err := g.SuggestGasFee(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	token := &TokenInfo{Symbol: "DAI", Contract: contract, Decimals: 18, GasLimit: erc20GasLimit}
+	receiver := common.HexToAddress("0x3535353535353535353535353535353535353535")
+	_, err = g.TransferToken(ctx, signer.NewLocalSigner(privateKey), token, receiver.Hex(), big.NewInt(1000), fee)
+	if err != ErrInsufficientBalance {
+		t.Errorf("expected ErrInsufficientBalance, got %v", err)
+	}
+}
+
+func TestEstimateGasCap(t *testing.T) {
+	ctx := context.Background()
+	backend, sender := newSimulated(t)
+	g := geth{client: backend.Client()}
+	receiver := common.HexToAddress("0x3535353535353535353535353535353535353535")
+
+	config.Config.Blockchain.EthGas.Cap = 20000
+	defer func() { config.Config.Blockchain.EthGas.Cap = 0 }()
+	if _, err := g.estimateGas(ctx, SignerAddress(sender), receiver, big.NewInt(1), nil, ethGasLimit); err != ErrGasExceedsCap {
+		t.Errorf("expected ErrGasExceedsCap, got %v", err)
+	}
+
+	// Sending more than the balance can never succeed, it must not fall
+	// back to the default limit.
+	tooMuch := new(big.Int).Mul(big.NewInt(1000), big.NewInt(1e18))
+	if _, err := g.estimateGas(ctx, SignerAddress(sender), receiver, tooMuch, nil, ethGasLimit); err == nil {
+		t.Error("expected insufficient funds error")
+	}
+}
+
+func TestEstimateGasReverted(t *testing.T) {
+	ctx := context.Background()
+	privateKey, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The stub contract reverts every call.
+	contract := common.HexToAddress("0x00000000000000000000000000000000000000e3")
+	backend := simulated.NewBackend(types.GenesisAlloc{
+		crypto.PubkeyToAddress(privateKey.PublicKey): {Balance: big.NewInt(1e18)},
+		contract: {Code: common.FromHex("0x60006000fd"), Balance: big.NewInt(0)},
+	})
+	t.Cleanup(func() { backend.Close() })
+	g := geth{client: backend.Client()}
+
+	data := erc20TransferData(contract, big.NewInt(1))
+	_, err = g.estimateGas(ctx, crypto.PubkeyToAddress(privateKey.PublicKey), contract, big.NewInt(0), data, erc20GasLimit)
+	if err == nil || !rejectedCall(err) {
+		t.Errorf("expected the revert, got %v", err)
+	}
+}
+
+// testRPCError is a JSON-RPC error answer as the rpc client decodes it.
+type testRPCError struct {
+	code    int
+	message string
+	data    interface{}
+}
+
+func (e testRPCError) Error() string          { return e.message }
+func (e testRPCError) ErrorCode() int         { return e.code }
+func (e testRPCError) ErrorData() interface{} { return e.data }
+
+func TestRejectedCall(t *testing.T) {
+	for _, tc := range []struct {
+		err      error
+		rejected bool
+	}{
+		{testRPCError{code: 3, message: "execution reverted: paused", data: "0x08c379a0"}, true},
+		{testRPCError{code: 3, message: "execution reverted"}, true},
+		{testRPCError{code: -32015, message: "vm error", data: "0x"}, true},
+		{testRPCError{code: -32000, message: "execution reverted"}, true},
+		{testRPCError{code: -32000, message: "insufficient funds for gas * price + value"}, true},
+		{testRPCError{code: -32000, message: "gas required exceeds allowance (30000000)"}, true},
+		{testRPCError{code: -32000, message: "intrinsic gas too low"}, true},
+		{testRPCError{code: -32000, message: "header not found"}, false},
+		{testRPCError{code: -32000, message: "request timed out"}, false},
+		{testRPCError{code: -32603, message: "internal error"}, false},
+		{errors.New("connection refused"), false},
+	} {
+		if got := rejectedCall(tc.err); got != tc.rejected {
+			t.Errorf("%v: rejected %v, want %v", tc.err, got, tc.rejected)
+		}
+	}
+}
+
+func TestTokenRegistry(t *testing.T) {
+	ctx := context.Background()
+	// The stub contract returns 18 to any call, enough for decimals().
+	contract := common.HexToAddress("0x00000000000000000000000000000000000000e2")
+	backend := simulated.NewBackend(types.GenesisAlloc{
+		contract: {Code: common.FromHex("0x601260005260206000f3"), Balance: big.NewInt(0)},
+	})
+	t.Cleanup(func() { backend.Close() })
+
+	config.Config.Blockchain.EthTokens = []config.EthTokenConfig{{Symbol: "dai", Contract: contract.Hex()}}
+	config.Config.Blockchain.EthUSDTContract = ""
+	g := NewGeth(backend.Client())
+
+	token, err := g.Token(ctx, "DAI")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token.Decimals != 18 || token.Contract != contract || token.GasLimit != erc20GasLimit {
+		t.Errorf("token %+v", token)
+	}
+	byContract, err := g.Token(ctx, contract.Hex())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if byContract.Symbol != "DAI" {
+		t.Errorf("token by contract %+v", byContract)
+	}
+	if _, err = g.Token(ctx, USDTSymbol); err != ErrUnknownToken {
+		t.Errorf("expected ErrUnknownToken, got %v", err)
+	}
+}
+
+func TestSafeTransactionHash(t *testing.T) {
+	safe := common.HexToAddress("0x1c8b9b78e3085866521fe206fa4c1a67f49f153a")
+	token := &TokenInfo{Contract: common.HexToAddress("0xe699595940072013B40FDf66C91A8FCfd08C4455")}
+	receiver := common.HexToAddress("0x3535353535353535353535353535353535353535")
+	tx := NewSafeTokenTransfer(token, receiver, big.NewInt(1500000), big.NewInt(7))
+	chainID := big.NewInt(11155111)
+
+	// The same SafeTx through the generic EIP-712 encoder.
+	typedData := apitypes.TypedData{
+		Types: apitypes.Types{
+			"EIP712Domain": {{Name: "chainId", Type: "uint256"}, {Name: "verifyingContract", Type: "address"}},
+			"SafeTx": {
+				{Name: "to", Type: "address"}, {Name: "value", Type: "uint256"}, {Name: "data", Type: "bytes"},
+				{Name: "operation", Type: "uint8"}, {Name: "safeTxGas", Type: "uint256"}, {Name: "baseGas", Type: "uint256"},
+				{Name: "gasPrice", Type: "uint256"}, {Name: "gasToken", Type: "address"}, {Name: "refundReceiver", Type: "address"},
+				{Name: "nonce", Type: "uint256"},
+			},
+		},
+		PrimaryType: "SafeTx",
+		Domain:      apitypes.TypedDataDomain{ChainId: (*math.HexOrDecimal256)(chainID), VerifyingContract: safe.Hex()},
+		Message: apitypes.TypedDataMessage{
+			"to": tx.To.Hex(), "value": "0", "data": tx.Data.String(), "operation": "0",
+			"safeTxGas": "0", "baseGas": "0", "gasPrice": "0",
+			"gasToken": common.Address{}.Hex(), "refundReceiver": common.Address{}.Hex(), "nonce": "7",
+		},
+	}
+	want, err := signer.HashTypedData(typedData, chainID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := tx.Hash(chainID, safe); !bytes.Equal(got.Bytes(), want.Digest) {
+		t.Errorf("safe tx hash %s, want %x", got, want.Digest)
+	}
+}
+
+func TestRecoverSafeSignature(t *testing.T) {
+	ctx := context.Background()
+	privateKey, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	owner := signer.NewLocalSigner(privateKey)
+	hash := crypto.Keccak256Hash([]byte("safe tx"))
+
+	signed, err := SignSafeTransaction(ctx, owner, hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := signed.Signature[64]; v != 27 && v != 28 {
+		t.Errorf("v = %d", v)
+	}
+	recovered, err := RecoverSafeSignature(hash, signed.Signature, false)
+	if err != nil || recovered.Owner != signed.Owner {
+		t.Errorf("recovered %v, %v", recovered, err)
+	}
+
+	// eth_sign over the hash is marked with v + 4.
+	ethSigned, err := signer.SignMessageHash(ctx, owner, signer.EthMessageHash(hash.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	recovered, err = RecoverSafeSignature(hash, ethSigned, true)
+	if err != nil || recovered.Owner != signed.Owner || recovered.Signature[64] < 31 {
+		t.Errorf("recovered eth_sign %v, %v", recovered, err)
+	}
+	if _, err = RecoverSafeSignature(hash, ethSigned[:64], false); err != ErrSafeSignatureFormat {
+		t.Errorf("expected ErrSafeSignatureFormat, got %v", err)
+	}
+}
+
+// safeFactoryABI is the part of the Safe singleton and proxy factory the
+// test deploys a Safe with.
+const safeFactoryABI = `[
+	{"type":"function","name":"setup","outputs":[],"inputs":[
+		{"name":"_owners","type":"address[]"},{"name":"_threshold","type":"uint256"},
+		{"name":"to","type":"address"},{"name":"data","type":"bytes"},
+		{"name":"fallbackHandler","type":"address"},{"name":"paymentToken","type":"address"},
+		{"name":"payment","type":"uint256"},{"name":"paymentReceiver","type":"address"}]},
+	{"type":"function","name":"createProxyWithNonce","outputs":[{"name":"proxy","type":"address"}],"inputs":[
+		{"name":"_singleton","type":"address"},{"name":"initializer","type":"bytes"},{"name":"saltNonce","type":"uint256"}]}
+]`
+
+// deployTestContract deploys the creation code in testdata/file.
+func deployTestContract(t *testing.T, backend *simulated.Backend, opts *bind.TransactOpts, file string) common.Address {
+	code, err := os.ReadFile(filepath.Join("testdata", file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	address, _, _, err := bind.DeployContract(opts, abi.ABI{}, common.FromHex(strings.TrimSpace(string(code))), backend.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+	backend.Commit()
+	return address
+}
+
+// deploySafe deploys the Safe v1.3.0 singleton and a proxy owned by owners
+// through the proxy factory.
+func deploySafe(t *testing.T, backend *simulated.Backend, opts *bind.TransactOpts, owners []common.Address, threshold int64) common.Address {
+	ctx := context.Background()
+	singleton := deployTestContract(t, backend, opts, "safe_v130.bin")
+	factory := deployTestContract(t, backend, opts, "safe_proxy_factory.bin")
+
+	factoryABI, err := abi.JSON(strings.NewReader(safeFactoryABI))
+	if err != nil {
+		t.Fatal(err)
+	}
+	setup, err := factoryABI.Pack("setup", owners, big.NewInt(threshold),
+		common.Address{}, []byte{}, common.Address{}, common.Address{}, big.NewInt(0), common.Address{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := factoryABI.Pack("createProxyWithNonce", singleton, setup, big.NewInt(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	output, err := backend.Client().CallContract(ctx, ethereum.CallMsg{From: opts.From, To: &factory, Data: data}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := factoryABI.Unpack("createProxyWithNonce", output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = bind.NewBoundContract(factory, factoryABI, nil, backend.Client(), nil).RawTransact(opts, data); err != nil {
+		t.Fatal(err)
+	}
+	backend.Commit()
+	return result[0].(common.Address)
+}
+
+func TestExecSafeTransaction(t *testing.T) {
+	ctx := context.Background()
+	keys := make([]*ecdsa.PrivateKey, 3)
+	owners := make([]common.Address, len(keys))
+	alloc := types.GenesisAlloc{}
+	for i := range keys {
+		key, err := crypto.GenerateKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys[i], owners[i] = key, crypto.PubkeyToAddress(key.PublicKey)
+		alloc[owners[i]] = types.Account{Balance: new(big.Int).Mul(big.NewInt(10), big.NewInt(1e18))}
+	}
+	backend := simulated.NewBackend(alloc)
+	t.Cleanup(func() { backend.Close() })
+	g := NewGeth(backend.Client())
+	chainID, err := g.GetChainID(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts, err := bind.NewKeyedTransactorWithChainID(keys[0], chainID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	safe := deploySafe(t, backend, opts, owners, 2)
+
+	executor := signer.NewLocalSigner(keys[0])
+	fee, err := g.SuggestGasFee(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = g.TransferETH(ctx, executor, safe.Hex(), big.NewInt(1e18), fee); err != nil {
+		t.Fatal(err)
+	}
+	backend.Commit()
+
+	info, err := g.GetSafeInfo(ctx, safe)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Nonce.Sign() != 0 || info.Threshold != 2 {
+		t.Fatalf("safe info %+v", info)
+	}
+	for _, owner := range []common.Address{owners[1], common.HexToAddress("0x3535353535353535353535353535353535353535")} {
+		isOwner, err := g.IsSafeOwner(ctx, safe, owner)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if isOwner != (owner == owners[1]) {
+			t.Errorf("isOwner(%s) = %v", owner.Hex(), isOwner)
+		}
+	}
+
+	receiver := common.HexToAddress("0x3535353535353535353535353535353535353535")
+	tx := NewSafeEthTransfer(receiver, big.NewInt(1000), info.Nonce)
+	hash := tx.Hash(chainID, safe)
+
+	// The first owner signs the EIP-712 hash, the second through eth_sign
+	// as a browser wallet would.
+	signature, err := SignSafeTransaction(ctx, executor, hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ethSigned, err := signer.SignMessageHash(ctx, signer.NewLocalSigner(keys[1]), signer.EthMessageHash(hash.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ethSignature, err := RecoverSafeSignature(hash, ethSigned, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ethSignature.Owner != owners[1] || ethSignature.Signature[crypto.RecoveryIDOffset] < 31 {
+		t.Fatalf("eth_sign signature %+v", ethSignature)
+	}
+
+	if _, err = g.ExecSafeTransaction(ctx, executor, safe, tx, []SafeSignature{*signature}, fee); err != ErrSafeThreshold {
+		t.Errorf("expected ErrSafeThreshold, got %v", err)
+	}
+	result, err := g.ExecSafeTransaction(ctx, executor, safe, tx, []SafeSignature{*ethSignature, *signature}, fee)
+	if err != nil {
+		t.Fatal(err)
+	}
+	backend.Commit()
+
+	receipt, err := g.GetTransactionReceipt(ctx, common.HexToHash(result.TxID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		t.Fatal("execTransaction failed")
+	}
+	// A Safe reports a failed inner call as ExecutionFailure without
+	// reverting, only the moved balance and the nonce prove it ran.
+	balance, err := g.GetBalance(ctx, receiver)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if balance.Cmp(big.NewInt(1000)) != 0 {
+		t.Errorf("receiver balance %s", balance)
+	}
+	if info, err = g.GetSafeInfo(ctx, safe); err != nil {
+		t.Fatal(err)
+	}
+	if info.Nonce.Int64() != 1 {
+		t.Errorf("safe nonce %s after execution", info.Nonce)
+	}
+}
@@ -0,0 +1,135 @@
+package eth
+
+import (
+	"context"
+	"math/big"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"wallet/pkg/common/config"
+)
+
+const (
+	defaultFeeHistoryBlocks = 10
+	defaultRewardPercentile = 50
+	// baseFeeMultiplier lets a dynamic fee survive that many consecutive
+	// full blocks, each of which raises the base fee by 12.5%.
+	baseFeeMultiplier = 2
+)
+
+// GasFee prices a transaction. GasPrice is set for legacy transactions,
+// GasTipCap and GasFeeCap for EIP-1559 dynamic fee transactions.
+type GasFee struct {
+	GasPrice  *big.Int `json:"gas_price,omitempty"`
+	GasTipCap *big.Int `json:"max_priority_fee_per_gas,omitempty"`
+	GasFeeCap *big.Int `json:"max_fee_per_gas,omitempty"`
+}
+
+func (f *GasFee) IsDynamic() bool {
+	return f.GasFeeCap != nil
+}
+
+// MaxPrice is the most a unit of gas can cost under f.
+func (f *GasFee) MaxPrice() *big.Int {
+	if f.IsDynamic() {
+		return f.GasFeeCap
+	}
+	return f.GasPrice
+}
+
+// MaxCost is the most gas units of gas can cost under f.
+func (f *GasFee) MaxCost(gas uint64) *big.Int {
+	return new(big.Int).Mul(f.MaxPrice(), new(big.Int).SetUint64(gas))
+}
+
+// SuggestGasFee prices the next transaction. Chains configured as legacy,
+// or whose latest block has no base fee, get a legacy gas price. Otherwise
+// the tip is the median of the configured reward percentile over the recent
+// blocks of eth_feeHistory and the fee cap leaves room for the base fee to
+// double.
+func (g geth) SuggestGasFee(ctx context.Context) (*GasFee, error) {
+	feeConfig := config.Config.Blockchain.EthFee
+	if feeConfig.Legacy {
+		return g.suggestLegacyFee(ctx)
+	}
+
+	blocks := feeConfig.FeeHistoryBlocks
+	if blocks == 0 {
+		blocks = defaultFeeHistoryBlocks
+	}
+	percentile := feeConfig.RewardPercentile
+	if percentile == 0 {
+		percentile = defaultRewardPercentile
+	}
+
+	history, err := g.client.FeeHistory(ctx, blocks, nil, []float64{percentile})
+	if err != nil {
+		return nil, err
+	}
+	// BaseFee has one entry more than blocks, the base fee of the next block.
+	if len(history.BaseFee) == 0 || history.BaseFee[len(history.BaseFee)-1].Sign() == 0 {
+		return g.suggestLegacyFee(ctx)
+	}
+	nextBaseFee := history.BaseFee[len(history.BaseFee)-1]
+
+	var rewards []*big.Int
+	for _, reward := range history.Reward {
+		if len(reward) > 0 {
+			rewards = append(rewards, reward[0])
+		}
+	}
+	// Quiet chains report zero rewards, ask the node for its minimum then.
+	tip := medianFee(rewards)
+	if tip == nil || tip.Sign() == 0 {
+		if tip, err = g.client.SuggestGasTipCap(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	feeCap := new(big.Int).Mul(nextBaseFee, big.NewInt(baseFeeMultiplier))
+	feeCap.Add(feeCap, tip)
+	return &GasFee{GasTipCap: tip, GasFeeCap: feeCap}, nil
+}
+
+func (g geth) suggestLegacyFee(ctx context.Context) (*GasFee, error) {
+	gasPrice, err := g.client.SuggestGasPrice(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &GasFee{GasPrice: gasPrice}, nil
+}
+
+// newTransaction builds a legacy or dynamic fee transaction depending on fee.
+func newTransaction(chainID *big.Int, nonce uint64, to common.Address, value *big.Int, gas uint64, fee *GasFee, data []byte) *types.Transaction {
+	if fee.IsDynamic() {
+		return types.NewTx(&types.DynamicFeeTx{
+			ChainID:   chainID,
+			Nonce:     nonce,
+			GasTipCap: fee.GasTipCap,
+			GasFeeCap: fee.GasFeeCap,
+			Gas:       gas,
+			To:        &to,
+			Value:     value,
+			Data:      data,
+		})
+	}
+	return types.NewTx(&types.LegacyTx{
+		Nonce:    nonce,
+		To:       &to,
+		Value:    value,
+		Gas:      gas,
+		GasPrice: fee.GasPrice,
+		Data:     data,
+	})
+}
+
+func medianFee(values []*big.Int) *big.Int {
+	if len(values) == 0 {
+		return nil
+	}
+	sorted := make([]*big.Int, len(values))
+	copy(sorted, values)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Cmp(sorted[j]) < 0 })
+	return new(big.Int).Set(sorted[len(sorted)/2])
+}
@@ -0,0 +1,119 @@
+package eth
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rpc"
+	"wallet/pkg/common/config"
+	"wallet/pkg/zlogger"
+)
+
+const (
+	defaultGasMultiplier = 1.2
+	defaultGasCap        = 1000000
+
+	// rpcRevertCode comes with the revert data of a call that reverted.
+	rpcRevertCode = 3
+	// rpcServerErrorCode is what geth answers for a call it refuses to
+	// run, e.g. one the sender cannot pay for, but providers use it for
+	// transient failures such as timeouts as well.
+	rpcServerErrorCode = -32000
+)
+
+// rejectionMessages identify the rpcServerErrorCode answers of a call the
+// node ran and refused.
+var rejectionMessages = []string{
+	"execution reverted",
+	"insufficient funds",
+	"gas required exceeds allowance",
+	"intrinsic gas too low",
+}
+
+// ErrGasExceedsCap is returned when a transaction needs more gas than the
+// configured cap allows.
+var ErrGasExceedsCap = errors.New("estimated gas exceeds the configured cap")
+
+// GasEstimate is the gas limit a transaction was sent with. Estimated is
+// what eth_estimateGas returned, zero when it failed and the default limit
+// was used instead.
+type GasEstimate struct {
+	Estimated uint64 `json:"estimated"`
+	GasLimit  uint64 `json:"gas_limit"`
+	Fallback  bool   `json:"fallback"`
+}
+
+// TransferResult describes a broadcast transaction, the limit and price it
+// was sent with. The gas it actually used is known once it is mined, see
+// GetTransactionInfo.
+type TransferResult struct {
+	TxID string      `json:"tx_id"`
+	Gas  GasEstimate `json:"gas"`
+	Fee  *GasFee     `json:"fee"`
+}
+
+// estimateGas estimates the call and adds the configured safety margin.
+// Calls that would revert fail right away, any other estimation error falls
+// back to defaultGas.
+func (g geth) estimateGas(ctx context.Context, from, to common.Address, value *big.Int, data []byte, defaultGas uint64) (*GasEstimate, error) {
+	gasConfig := config.Config.Blockchain.EthGas
+	multiplier := gasConfig.Multiplier
+	if multiplier == 0 {
+		multiplier = defaultGasMultiplier
+	}
+	gasCap := gasConfig.Cap
+	if gasCap == 0 {
+		gasCap = defaultGasCap
+	}
+
+	estimated, err := g.EstimateGasLimit(ctx, from, to, value, data)
+	if err != nil {
+		if rejectedCall(err) {
+			return nil, err
+		}
+		zlogger.Warnf("[estimateGas] estimate gas to %s error %v, using default %d", to.Hex(), err, defaultGas)
+		return &GasEstimate{GasLimit: defaultGas, Fallback: true}, nil
+	}
+
+	// A plain transfer to an account without code costs exactly that much.
+	gasLimit := estimated
+	if estimated != ethGasLimit || len(data) > 0 {
+		gasLimit = uint64(float64(estimated) * multiplier)
+	}
+	if gasLimit > gasCap {
+		if estimated > gasCap {
+			return nil, ErrGasExceedsCap
+		}
+		gasLimit = gasCap
+	}
+	return &GasEstimate{Estimated: estimated, GasLimit: gasLimit}, nil
+}
+
+// rejectedCall reports whether the node ran the call and refused it, a
+// revert or a transaction the sender cannot pay for, rather than failing to
+// estimate it.
+func rejectedCall(err error) bool {
+	var dataErr rpc.DataError
+	if errors.As(err, &dataErr) && dataErr.ErrorData() != nil {
+		return true
+	}
+	var rpcErr rpc.Error
+	if !errors.As(err, &rpcErr) {
+		return false
+	}
+	switch rpcErr.ErrorCode() {
+	case rpcRevertCode:
+		return true
+	case rpcServerErrorCode:
+		message := strings.ToLower(err.Error())
+		for _, rejection := range rejectionMessages {
+			if strings.Contains(message, rejection) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,264 @@
+package eth
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"math/big"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"wallet/pkg/signer"
+)
+
+// The part of the Safe (formerly Gnosis Safe) v1.3+ interface the wallet
+// uses.
+const safeABIJSON = `[
+	{"type":"function","name":"nonce","stateMutability":"view","inputs":[],"outputs":[{"type":"uint256"}]},
+	{"type":"function","name":"getThreshold","stateMutability":"view","inputs":[],"outputs":[{"type":"uint256"}]},
+	{"type":"function","name":"isOwner","stateMutability":"view","inputs":[{"name":"owner","type":"address"}],"outputs":[{"type":"bool"}]},
+	{"type":"function","name":"execTransaction","stateMutability":"payable","inputs":[
+		{"name":"to","type":"address"},{"name":"value","type":"uint256"},{"name":"data","type":"bytes"},
+		{"name":"operation","type":"uint8"},{"name":"safeTxGas","type":"uint256"},{"name":"baseGas","type":"uint256"},
+		{"name":"gasPrice","type":"uint256"},{"name":"gasToken","type":"address"},{"name":"refundReceiver","type":"address"},
+		{"name":"signatures","type":"bytes"}],"outputs":[{"name":"success","type":"bool"}]}
+]`
+
+// Safe operations, delegate calls are never built by the wallet.
+const (
+	SafeOperationCall         uint8 = 0
+	SafeOperationDelegateCall uint8 = 1
+)
+
+var (
+	safeABI = mustParseABI(safeABIJSON)
+
+	safeDomainTypeHash = crypto.Keccak256Hash([]byte("EIP712Domain(uint256 chainId,address verifyingContract)"))
+	safeTxTypeHash     = crypto.Keccak256Hash([]byte("SafeTx(address to,uint256 value,bytes data,uint8 operation,uint256 safeTxGas,uint256 baseGas,uint256 gasPrice,address gasToken,address refundReceiver,uint256 nonce)"))
+)
+
+var (
+	ErrSafeSignatureFormat = errors.New("safe signature must be 65 bytes with v 27, 28, 31 or 32")
+	ErrSafeThreshold       = errors.New("not enough safe owner signatures")
+)
+
+func mustParseABI(definition string) abi.ABI {
+	parsed, err := abi.JSON(strings.NewReader(definition))
+	if err != nil {
+		panic(err)
+	}
+	return parsed
+}
+
+// SafeTransaction is the SafeTx struct the owners sign. Refunds are not
+// used by the wallet, SafeTxGas, BaseGas and GasPrice stay zero so the
+// executor pays the gas.
+type SafeTransaction struct {
+	To             common.Address `json:"to"`
+	Value          *hexutil.Big   `json:"value"`
+	Data           hexutil.Bytes  `json:"data"`
+	Operation      uint8          `json:"operation"`
+	SafeTxGas      *hexutil.Big   `json:"safe_tx_gas"`
+	BaseGas        *hexutil.Big   `json:"base_gas"`
+	GasPrice       *hexutil.Big   `json:"gas_price"`
+	GasToken       common.Address `json:"gas_token"`
+	RefundReceiver common.Address `json:"refund_receiver"`
+	Nonce          *hexutil.Big   `json:"nonce"`
+}
+
+// NewSafeEthTransfer sends value wei from the Safe to receiver.
+func NewSafeEthTransfer(receiver common.Address, value *big.Int, nonce *big.Int) *SafeTransaction {
+	return newSafeTransaction(receiver, value, nil, nonce)
+}
+
+// NewSafeTokenTransfer sends amount of token from the Safe to receiver.
+func NewSafeTokenTransfer(token *TokenInfo, receiver common.Address, amount *big.Int, nonce *big.Int) *SafeTransaction {
+	return newSafeTransaction(token.Contract, big.NewInt(0), erc20TransferData(receiver, amount), nonce)
+}
+
+func newSafeTransaction(to common.Address, value *big.Int, data []byte, nonce *big.Int) *SafeTransaction {
+	return &SafeTransaction{
+		To:        to,
+		Value:     (*hexutil.Big)(value),
+		Data:      data,
+		Operation: SafeOperationCall,
+		SafeTxGas: new(hexutil.Big),
+		BaseGas:   new(hexutil.Big),
+		GasPrice:  new(hexutil.Big),
+		Nonce:     (*hexutil.Big)(nonce),
+	}
+}
+
+// Hash is the EIP-712 digest of tx for the Safe at safe on chainID, the
+// value owners sign and the Safe checks in execTransaction.
+func (tx *SafeTransaction) Hash(chainID *big.Int, safe common.Address) common.Hash {
+	domainSeparator := crypto.Keccak256Hash(
+		safeDomainTypeHash.Bytes(),
+		common.LeftPadBytes(chainID.Bytes(), 32),
+		common.LeftPadBytes(safe.Bytes(), 32),
+	)
+	structHash := crypto.Keccak256Hash(
+		safeTxTypeHash.Bytes(),
+		common.LeftPadBytes(tx.To.Bytes(), 32),
+		common.LeftPadBytes(tx.Value.ToInt().Bytes(), 32),
+		crypto.Keccak256(tx.Data),
+		common.LeftPadBytes([]byte{tx.Operation}, 32),
+		common.LeftPadBytes(tx.SafeTxGas.ToInt().Bytes(), 32),
+		common.LeftPadBytes(tx.BaseGas.ToInt().Bytes(), 32),
+		common.LeftPadBytes(tx.GasPrice.ToInt().Bytes(), 32),
+		common.LeftPadBytes(tx.GasToken.Bytes(), 32),
+		common.LeftPadBytes(tx.RefundReceiver.Bytes(), 32),
+		common.LeftPadBytes(tx.Nonce.ToInt().Bytes(), 32),
+	)
+	return crypto.Keccak256Hash([]byte{0x19, 0x01}, domainSeparator.Bytes(), structHash.Bytes())
+}
+
+// SafeSignature is the signature of one owner in the form execTransaction
+// takes it, V is 27 or 28 for signatures of the hash itself and 31 or 32
+// for eth_sign signatures of it.
+type SafeSignature struct {
+	Owner     common.Address `json:"owner"`
+	Signature hexutil.Bytes  `json:"signature"`
+}
+
+// SignSafeTransaction signs the Safe transaction hash with sender.
+func SignSafeTransaction(ctx context.Context, sender signer.Signer, hash common.Hash) (*SafeSignature, error) {
+	signature, err := sender.SignHash(ctx, hash.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	signature[crypto.RecoveryIDOffset] += 27
+	return &SafeSignature{Owner: SignerAddress(sender), Signature: signature}, nil
+}
+
+// RecoverSafeSignature returns the owner behind an externally made
+// signature of hash. Wallets that can only eth_sign produce V 27 or 28
+// over the prefixed hash, those are marked with V + 4 as the Safe expects.
+// Signatures with V 31 or 32 are taken to be eth_sign ones already.
+func RecoverSafeSignature(hash common.Hash, signature []byte, ethSign bool) (*SafeSignature, error) {
+	if len(signature) != crypto.SignatureLength {
+		return nil, ErrSafeSignatureFormat
+	}
+	sig := bytes.Clone(signature)
+	v := sig[crypto.RecoveryIDOffset]
+	if v < 27 {
+		v += 27
+	}
+	if ethSign && v <= 28 {
+		v += 4
+	}
+
+	digest := hash.Bytes()
+	switch v {
+	case 27, 28:
+	case 31, 32:
+		digest = accounts.TextHash(hash.Bytes())
+	default:
+		return nil, ErrSafeSignatureFormat
+	}
+	sig[crypto.RecoveryIDOffset] = (v - 27) % 4
+
+	publicKey, err := crypto.SigToPub(digest, sig)
+	if err != nil {
+		return nil, ErrSafeSignatureFormat
+	}
+	sig[crypto.RecoveryIDOffset] = v
+	return &SafeSignature{Owner: crypto.PubkeyToAddress(*publicKey), Signature: sig}, nil
+}
+
+// PackSafeSignatures concatenates signatures ordered by owner, the order
+// execTransaction requires.
+func PackSafeSignatures(signatures []SafeSignature) []byte {
+	sorted := append([]SafeSignature(nil), signatures...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return bytes.Compare(sorted[i].Owner.Bytes(), sorted[j].Owner.Bytes()) < 0
+	})
+	var packed []byte
+	for _, signature := range sorted {
+		packed = append(packed, signature.Signature...)
+	}
+	return packed
+}
+
+// SafeInfo is the on-chain state of a Safe relevant to a new transaction.
+type SafeInfo struct {
+	Address   common.Address `json:"address"`
+	Nonce     *big.Int       `json:"nonce"`
+	Threshold uint64         `json:"threshold"`
+}
+
+func (g geth) callSafe(ctx context.Context, safe common.Address, method string, args ...interface{}) ([]interface{}, error) {
+	data, err := safeABI.Pack(method, args...)
+	if err != nil {
+		return nil, err
+	}
+	output, err := g.client.CallContract(ctx, ethereum.CallMsg{To: &safe, Data: data}, nil)
+	if err != nil {
+		return nil, err
+	}
+	return safeABI.Unpack(method, output)
+}
+
+func (g geth) GetSafeInfo(ctx context.Context, safe common.Address) (*SafeInfo, error) {
+	nonce, err := g.callSafe(ctx, safe, "nonce")
+	if err != nil {
+		return nil, err
+	}
+	threshold, err := g.callSafe(ctx, safe, "getThreshold")
+	if err != nil {
+		return nil, err
+	}
+	return &SafeInfo{
+		Address:   safe,
+		Nonce:     nonce[0].(*big.Int),
+		Threshold: threshold[0].(*big.Int).Uint64(),
+	}, nil
+}
+
+func (g geth) IsSafeOwner(ctx context.Context, safe, owner common.Address) (bool, error) {
+	result, err := g.callSafe(ctx, safe, "isOwner", owner)
+	if err != nil {
+		return false, err
+	}
+	return result[0].(bool), nil
+}
+
+// ExecSafeTransaction submits tx with the collected signatures through
+// execTransaction, sender pays the gas. The signatures must reach the
+// threshold, the Safe rejects the call otherwise.
+func (g geth) ExecSafeTransaction(
+	ctx context.Context,
+	sender signer.Signer,
+	safe common.Address,
+	tx *SafeTransaction,
+	signatures []SafeSignature,
+	fee *GasFee,
+) (*TransferResult, error) {
+	info, err := g.GetSafeInfo(ctx, safe)
+	if err != nil {
+		return nil, err
+	}
+	if uint64(len(signatures)) < info.Threshold {
+		return nil, ErrSafeThreshold
+	}
+
+	data, err := safeABI.Pack("execTransaction",
+		tx.To, tx.Value.ToInt(), []byte(tx.Data), tx.Operation,
+		tx.SafeTxGas.ToInt(), tx.BaseGas.ToInt(), tx.GasPrice.ToInt(),
+		tx.GasToken, tx.RefundReceiver, PackSafeSignatures(signatures),
+	)
+	if err != nil {
+		return nil, err
+	}
+	gas, err := g.estimateGas(ctx, SignerAddress(sender), safe, big.NewInt(0), data, safeExecGasLimit)
+	if err != nil {
+		return nil, err
+	}
+	return g.sendTransaction(ctx, sender, safe, big.NewInt(0), data, gas, fee)
+}
@@ -0,0 +1,123 @@
+package eth
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"golang.org/x/crypto/sha3"
+	"wallet/pkg/common/config"
+	"wallet/pkg/hdwallet/registry"
+)
+
+const USDTSymbol = "USDT"
+
+var ErrUnknownToken = registry.ErrUnknownToken
+
+// TokenInfo is an ERC-20 token of the registry, Decimals is read from the
+// contract the first time the token is used.
+type TokenInfo struct {
+	Symbol   string         `json:"symbol"`
+	Contract common.Address `json:"contract"`
+	Decimals uint8          `json:"decimals"`
+	// GasLimit is used when estimating a transfer fails.
+	GasLimit uint64 `json:"-"`
+}
+
+// tokenRegistry is the shared registry keyed by checksummed contract, plus
+// the fallback gas limit of each token.
+type tokenRegistry struct {
+	*registry.Tokens
+	gasLimits map[common.Address]uint64
+}
+
+// newTokenRegistry registers tokens, plus the legacy USDT contract under
+// USDT unless the list already names that symbol.
+func newTokenRegistry(tokens []config.EthTokenConfig, usdtContract string) *tokenRegistry {
+	r := &tokenRegistry{
+		Tokens: registry.NewTokens(func(contract string) string {
+			if common.IsHexAddress(contract) {
+				return common.HexToAddress(contract).Hex()
+			}
+			return contract
+		}),
+		gasLimits: make(map[common.Address]uint64),
+	}
+	for _, token := range tokens {
+		r.add(token.Symbol, common.HexToAddress(token.Contract), token.GasLimit)
+	}
+	if !r.Has(USDTSymbol) && common.IsHexAddress(usdtContract) {
+		r.add(USDTSymbol, common.HexToAddress(usdtContract), 0)
+	}
+	return r
+}
+
+func (r *tokenRegistry) add(symbol string, contract common.Address, gasLimit uint64) {
+	if gasLimit == 0 {
+		gasLimit = erc20GasLimit
+	}
+	r.Add(symbol, contract.Hex())
+	r.gasLimits[contract] = gasLimit
+}
+
+func (r *tokenRegistry) info(token registry.Token) TokenInfo {
+	contract := common.HexToAddress(token.Contract)
+	return TokenInfo{
+		Symbol:   token.Symbol,
+		Contract: contract,
+		Decimals: uint8(token.Decimals),
+		GasLimit: r.gasLimits[contract],
+	}
+}
+
+// Token resolves a registered token by symbol or contract address.
+func (g geth) Token(ctx context.Context, key string) (*TokenInfo, error) {
+	token, err := g.tokens.Resolve(key, func(contract string) (int, error) {
+		instance, err := NewToken(common.HexToAddress(contract), g.client)
+		if err != nil {
+			return 0, err
+		}
+		decimals, err := instance.Decimals(&bind.CallOpts{Context: ctx})
+		return int(decimals), err
+	})
+	if err != nil {
+		return nil, err
+	}
+	info := g.tokens.info(*token)
+	return &info, nil
+}
+
+// Tokens lists the registered tokens without touching the chain.
+func (g geth) Tokens() []TokenInfo {
+	tokens := g.tokens.List()
+	list := make([]TokenInfo, 0, len(tokens))
+	for _, token := range tokens {
+		list = append(list, g.tokens.info(token))
+	}
+	return list
+}
+
+func (g geth) GetTokenBalance(ctx context.Context, token *TokenInfo, address common.Address) (*big.Int, error) {
+	instance, err := NewToken(token.Contract, g.client)
+	if err != nil {
+		return nil, err
+	}
+	return instance.BalanceOf(&bind.CallOpts{Context: ctx}, address)
+}
+
+// erc20TransferData is the calldata of transfer(receiver, amount).
+func erc20TransferData(receiver common.Address, amount *big.Int) []byte {
+	hash := sha3.NewLegacyKeccak256()
+	hash.Write([]byte("transfer(address,uint256)"))
+	methodID := hash.Sum(nil)[:4]
+
+	paddedAddress := common.LeftPadBytes(receiver.Bytes(), leftPadBytesLength)
+	paddedAmount := common.LeftPadBytes(amount.Bytes(), leftPadBytesLength)
+
+	var data []byte
+	data = append(data, methodID...)
+	data = append(data, paddedAddress...)
+	data = append(data, paddedAmount...)
+	return data
+}
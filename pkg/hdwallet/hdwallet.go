@@ -2,24 +2,34 @@ package hdwallet
 
 import (
 	"crypto/ecdsa"
-	"errors"
 	"fmt"
 	geth "github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/crypto"
 	tronSdk "github.com/fbsobreira/gotron-sdk/pkg/address"
 	"github.com/tyler-smith/go-bip32"
 	"github.com/tyler-smith/go-bip39"
+	"golang.org/x/text/unicode/norm"
 	"wallet/pkg/zlogger"
 )
 
-func NewMnemonic() (string, error) {
-	entropy, err := bip39.NewEntropy(128)
+// NewMnemonic generates a mnemonic of wordCount words from the wordlist of
+// language, see Languages for the supported values.
+func NewMnemonic(wordCount int, language string) (string, error) {
+	bitSize, err := MnemonicEntropyBits(wordCount)
+	if err != nil {
+		return "", err
+	}
+	if _, ok := wordLists[language]; !ok {
+		return "", ErrUnsupportedLanguage
+	}
+
+	entropy, err := bip39.NewEntropy(bitSize)
 	if err != nil {
 		zlogger.Errorf("Failed to generate entropy: %v", err)
 		return "", err
 	}
 
-	mnemonic, err := bip39.NewMnemonic(entropy)
+	mnemonic, err := encodeMnemonic(entropy, language)
 	if err != nil {
 		zlogger.Errorf("Failed to generate mnemonic: %v", err)
 		return "", err
@@ -27,13 +37,28 @@ func NewMnemonic() (string, error) {
 	return mnemonic, nil
 }
 
-func GetMasterKeyByMnemonic(mnemonic string) (*bip32.Key, error) {
-	if !bip39.IsMnemonicValid(mnemonic) {
+// GetMasterKeyByMnemonic builds the BIP32 master key, passphrase is the
+// optional BIP39 "25th word" and must be empty for unprotected wallets.
+func GetMasterKeyByMnemonic(mnemonic string, passphrase string) (*bip32.Key, error) {
+	seed, err := NewSeed(mnemonic, passphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	return GetMasterKeyBySeed(seed)
+}
+
+// NewSeed validates mnemonic and stretches it together with passphrase into
+// the 64 byte BIP39 seed.
+func NewSeed(mnemonic string, passphrase string) ([]byte, error) {
+	if !IsMnemonicValid(mnemonic) {
 		zlogger.Errorf("Invalid mnemonic")
-		return nil, errors.New("mnemonic is invalid")
+		return nil, ErrInvalidMnemonic
 	}
-	seed := bip39.NewSeed(mnemonic, "")
+	return bip39.NewSeed(norm.NFKD.String(mnemonic), norm.NFKD.String(passphrase)), nil
+}
 
+func GetMasterKeyBySeed(seed []byte) (*bip32.Key, error) {
 	masterKey, err := bip32.NewMasterKey(seed)
 	if err != nil {
 		zlogger.Errorf("Failed to get master key: %v", err)
@@ -45,48 +70,29 @@ func GetMasterKeyByMnemonic(mnemonic string) (*bip32.Key, error) {
 
 func DeriveEthAddress(privateKey *ecdsa.PrivateKey) geth.Address {
 	publicKey := privateKey.Public().(*ecdsa.PublicKey)
-	ethAddress := crypto.PubkeyToAddress(*publicKey)
-	return ethAddress
+	return PublicKeyToEthAddress(publicKey)
 }
 
 func DeriveTronAddress(privateKey *ecdsa.PrivateKey) tronSdk.Address {
 	publicKey := privateKey.Public().(*ecdsa.PublicKey)
-	tronAddress := tronSdk.PubkeyToAddress(*publicKey)
-	return tronAddress
+	return PublicKeyToTronAddress(publicKey)
 }
 
 func DerivePrivateKey(masterKey *bip32.Key, coinType uint32) (*ecdsa.PrivateKey, error) {
 	// Derivation path: m/44'/coinType'/0'/0/0
-	purpose, err := masterKey.NewChildKey(bip32.FirstHardenedChild + 44)
-	if err != nil {
-		return nil, fmt.Errorf("failed to derive purpose: %v", err)
-	}
-
-	coin, err := purpose.NewChildKey(bip32.FirstHardenedChild + coinType)
-	if err != nil {
-		return nil, fmt.Errorf("failed to derive coin type: %v", err)
-	}
-
-	account, err := coin.NewChildKey(bip32.FirstHardenedChild + 0)
-	if err != nil {
-		return nil, fmt.Errorf("failed to derive account: %v", err)
-	}
-
-	change, err := account.NewChildKey(0)
-	if err != nil {
-		return nil, fmt.Errorf("failed to derive change: %v", err)
-	}
+	return DerivePrivateKeyByPath(masterKey, DefaultDerivationPath(coinType))
+}
 
-	addressKey, err := change.NewChildKey(0)
+func DerivePrivateKeyByPath(masterKey *bip32.Key, path DerivationPath) (*ecdsa.PrivateKey, error) {
+	key, err := path.Derive(masterKey)
 	if err != nil {
-		return nil, fmt.Errorf("failed to derive address key: %v", err)
+		return nil, err
 	}
 
-	privateKey, err := crypto.ToECDSA(addressKey.Key)
+	privateKey, err := crypto.ToECDSA(key.Key)
 	if err != nil {
 		return nil, fmt.Errorf("failed to convert to ECDSA: %v", err)
 	}
 
 	return privateKey, nil
-
 }
@@ -0,0 +1,310 @@
+package hdwallet
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/tyler-smith/go-bip32"
+)
+
+const testMnemonic = "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+
+func TestDerivePrivateKey(t *testing.T) {
+	masterKey, err := GetMasterKeyByMnemonic(testMnemonic, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ethKey, err := DerivePrivateKey(masterKey, 60)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := DeriveEthAddress(ethKey).Hex(); got != "0x9858EfFD232B4033E47d90003D41EC34EcaEda94" {
+		t.Errorf("eth address = %s", got)
+	}
+
+	tronKey, err := DerivePrivateKey(masterKey, 195)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := DeriveTronAddress(tronKey).String(); got != "TUEZSdKsoDHQMeZwihtdoBiN46zxhGWYdH" {
+		t.Errorf("tron address = %s", got)
+	}
+}
+
+func TestDerivePrivateKeyByPath(t *testing.T) {
+	masterKey, err := GetMasterKeyByMnemonic(testMnemonic, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path, err := ParseDerivationPath("m/44'/60'/0'/0/1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := path.String(); got != "m/44'/60'/0'/0/1" {
+		t.Errorf("path = %s", got)
+	}
+
+	key, err := DerivePrivateKeyByPath(masterKey, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := DeriveEthAddress(key).Hex(); got != "0x6Fac4D18c912343BF86fa7049364Dd4E424Ab9C0" {
+		t.Errorf("eth address = %s", got)
+	}
+
+	defaultKey, err := DerivePrivateKeyByPath(masterKey, NewDerivationPath(60, 0, 0, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := DeriveEthAddress(defaultKey).Hex(); got != "0x9858EfFD232B4033E47d90003D41EC34EcaEda94" {
+		t.Errorf("eth address = %s", got)
+	}
+}
+
+func TestParseDerivationPath(t *testing.T) {
+	for _, path := range []string{"", "44'/60'", "m/", "m/44'/x", "m/2147483648"} {
+		if _, err := ParseDerivationPath(path); err == nil {
+			t.Errorf("expected error for %q", path)
+		}
+	}
+	path, err := ParseDerivationPath("m/44h/195h/3h/1/7")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := path.String(); got != "m/44'/195'/3'/1/7" {
+		t.Errorf("path = %s", got)
+	}
+
+	if err = path.CheckCoinType(195); err != nil {
+		t.Errorf("check coin type: %v", err)
+	}
+	for _, coinType := range []uint32{60, 195 + 1} {
+		if err = path.CheckCoinType(coinType); !errors.Is(err, ErrInvalidDerivationPath) {
+			t.Errorf("coin type %d: expected ErrInvalidDerivationPath, got %v", coinType, err)
+		}
+	}
+	for _, other := range []DerivationPath{{bip32.FirstHardenedChild + 49, bip32.FirstHardenedChild + 195}, {bip32.FirstHardenedChild + bip44Purpose}} {
+		if err = other.CheckCoinType(195); !errors.Is(err, ErrInvalidDerivationPath) {
+			t.Errorf("%s: expected ErrInvalidDerivationPath, got %v", other, err)
+		}
+	}
+}
+
+func TestGetMasterKeyByMnemonicPassphrase(t *testing.T) {
+	masterKey, err := GetMasterKeyByMnemonic(testMnemonic, "TREZOR")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := masterKey.B58Serialize(); got != "xprv9s21ZrQH143K3h3fDYiay8mocZ3afhfULfb5GX8kCBdno77K4HiA15Tg23wpbeF1pLfs1c5SPmYHrEpTuuRhxMwvKDwqdKiGJS9XFKzUsAF" {
+		t.Errorf("master key = %s", got)
+	}
+}
+
+func TestNewMnemonic(t *testing.T) {
+	for _, language := range Languages {
+		for _, words := range []int{12, 15, 18, 21, 24} {
+			mnemonic, err := NewMnemonic(words, language)
+			if err != nil {
+				t.Fatalf("%s/%d: %v", language, words, err)
+			}
+			detected, err := DetectMnemonicLanguage(mnemonic)
+			if err != nil {
+				t.Fatalf("%s/%d: %v", language, words, err)
+			}
+			// Chinese simplified and traditional share enough words that a
+			// mnemonic can be valid in both.
+			if detected != language && language != LanguageChineseTraditional {
+				t.Errorf("%s/%d detected as %s", language, words, detected)
+			}
+		}
+	}
+	if _, err := NewMnemonic(13, LanguageEnglish); err == nil {
+		t.Error("expected word count error")
+	}
+	if _, err := NewMnemonic(12, "klingon"); err == nil {
+		t.Error("expected language error")
+	}
+}
+
+func TestJapaneseMnemonicSeed(t *testing.T) {
+	// Mnemonic and passphrase are both NFKD normalized before seeding.
+	mnemonic := "あいこくしん　あいこくしん　あいこくしん　あいこくしん　あいこくしん　あいこくしん　あいこくしん　あいこくしん　あいこくしん　あいこくしん　あいこくしん　あおぞら"
+	masterKey, err := GetMasterKeyByMnemonic(mnemonic, "㍍ガバヴァぱばぐゞちぢ十人十色")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := masterKey.B58Serialize(); got != "xprv9s21ZrQH143K258jAiWPAM6JYT9hLA91MV3AZUKfxmLZJCjCHeSjBvMbDy8C1mJ2FL5ytExyS97FAe6pQ6SD5Jt9SwHaLorA8i5Eojokfo1" {
+		t.Errorf("master key = %s", got)
+	}
+}
+
+func TestDeriveFromXpub(t *testing.T) {
+	masterKey, err := GetMasterKeyByMnemonic(testMnemonic, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, coinType := range []uint32{60, 195} {
+		xpub, err := ExportAccountXpub(masterKey, coinType, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		accountKey, err := ParseXpub(xpub)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for index := uint32(0); index < 3; index++ {
+			privateKey, err := DerivePrivateKeyByPath(masterKey, NewDerivationPath(coinType, 0, 0, index))
+			if err != nil {
+				t.Fatal(err)
+			}
+			publicKey, err := DerivePublicKeyFromXpub(accountKey, 0, index)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if PublicKeyToEthAddress(publicKey) != DeriveEthAddress(privateKey) {
+				t.Errorf("coin %d index %d: xpub address mismatch", coinType, index)
+			}
+		}
+	}
+
+	accountKey, err := DeriveAccountKey(masterKey, 60, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParseXpub(accountKey.B58Serialize()); err != ErrNotPublicKey {
+		t.Errorf("expected ErrNotPublicKey, got %v", err)
+	}
+
+	// Indexes past 2^31 would silently select hardened children.
+	if _, err = DeriveAccountKey(masterKey, 60, bip32.FirstHardenedChild); err != ErrIndexOutOfRange {
+		t.Errorf("account: expected ErrIndexOutOfRange, got %v", err)
+	}
+	if _, err = DerivePublicKeyFromXpub(accountKey.PublicKey(), 0, bip32.FirstHardenedChild); err != ErrIndexOutOfRange {
+		t.Errorf("index: expected ErrIndexOutOfRange, got %v", err)
+	}
+}
+
+func TestKeySource(t *testing.T) {
+	masterKey, err := GetMasterKeyByMnemonic(testMnemonic, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := NewDerivationPath(195, 0, 0, 0)
+	derived, err := NewMasterKeySource(masterKey).PrivateKey(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	source, err := NewPrivateKeySourceFromHex("0x" + hex.EncodeToString(crypto.FromECDSA(derived)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	imported, err := source.PrivateKey(NewDerivationPath(60, 0, 0, 9))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if DeriveTronAddress(imported).String() != DeriveTronAddress(derived).String() {
+		t.Error("raw key source must ignore the path")
+	}
+
+	if _, err = NewPrivateKeySourceFromHex("0x1234"); err != ErrInvalidPrivateKey {
+		t.Errorf("expected ErrInvalidPrivateKey, got %v", err)
+	}
+}
+
+func TestShamirMnemonic(t *testing.T) {
+	for _, mnemonic := range []string{testMnemonic, mustNewMnemonic(t, 24, LanguageJapanese)} {
+		shares, err := SplitMnemonic(mnemonic, 3, 5)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, subset := range [][]int{{0, 1, 2}, {4, 2, 0}, {1, 3, 4, 0}} {
+			var picked []string
+			for _, i := range subset {
+				picked = append(picked, shares[i])
+			}
+			got, err := CombineMnemonic(picked)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != mnemonic {
+				t.Errorf("shares %v recovered %q", subset, got)
+			}
+		}
+
+		if _, err = CombineMnemonic([]string{shares[0], shares[1], shares[1]}); err != ErrNotEnoughShares {
+			t.Errorf("expected ErrNotEnoughShares, got %v", err)
+		}
+		tampered := []byte(shares[2])
+		tampered[10] ^= 1
+		if _, err = CombineMnemonic([]string{shares[0], shares[1], string(tampered)}); !errors.Is(err, ErrInvalidShare) {
+			t.Errorf("expected ErrInvalidShare, got %v", err)
+		}
+	}
+
+	for _, shares := range [][]string{nil, {}} {
+		if _, err := CombineMnemonic(shares); err != ErrNotEnoughShares {
+			t.Errorf("%d shares: expected ErrNotEnoughShares, got %v", len(shares), err)
+		}
+	}
+	if _, err := SplitMnemonic(testMnemonic, 1, 3); err != ErrInvalidThreshold {
+		t.Errorf("expected ErrInvalidThreshold, got %v", err)
+	}
+}
+
+func mustNewMnemonic(t *testing.T, words int, language string) string {
+	mnemonic, err := NewMnemonic(words, language)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return mnemonic
+}
+
+func TestDiscoverAddresses(t *testing.T) {
+	masterKey, err := GetMasterKeyByMnemonic(testMnemonic, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Used: account 0 external 0, 3 and 7, change 1, account 1 external 2.
+	// Account 0 index 30 lies beyond the gap and must not be found.
+	history := map[string]bool{}
+	for _, p := range [][3]uint32{{0, 0, 0}, {0, 0, 3}, {0, 0, 7}, {0, 1, 1}, {1, 0, 2}, {0, 0, 30}} {
+		key, err := DerivePrivateKeyByPath(masterKey, NewDerivationPath(60, p[0], p[1], p[2]))
+		if err != nil {
+			t.Fatal(err)
+		}
+		history[DeriveEthAddress(key).Hex()] = true
+	}
+	checked := 0
+	used := func(_ context.Context, publicKey *ecdsa.PublicKey) (bool, error) {
+		checked++
+		return history[PublicKeyToEthAddress(publicKey).Hex()], nil
+	}
+
+	found, err := DiscoverAddresses(context.Background(), masterKey, 60, 5, 10, used)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var paths []string
+	for _, address := range found {
+		paths = append(paths, address.Path.String())
+	}
+	want := []string{"m/44'/60'/0'/0/0", "m/44'/60'/0'/0/3", "m/44'/60'/0'/0/7", "m/44'/60'/0'/1/1", "m/44'/60'/1'/0/2"}
+	if strings.Join(paths, ",") != strings.Join(want, ",") {
+		t.Errorf("found %v, want %v", paths, want)
+	}
+	// account 0: 13 + 7, account 1: 8 + 5, account 2: 5 + 5
+	if checked != 43 {
+		t.Errorf("checked %d addresses", checked)
+	}
+}
@@ -0,0 +1,211 @@
+package keystore
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/pbkdf2"
+	"wallet/pkg/tools/secretbox"
+)
+
+// Key derivation functions of Web3 Secret Storage v3.
+const (
+	KdfScrypt = "scrypt"
+	KdfPbkdf2 = "pbkdf2"
+)
+
+const (
+	version       = 3
+	pbkdf2Rounds  = 262144
+	derivedKeyLen = 32
+	saltLen       = 32
+	pbkdf2Prf     = "hmac-sha256"
+)
+
+// Upper bounds on the work a keystore may ask of Decrypt. Keystores arrive in
+// request bodies, without them one file can pin the CPU or exhaust memory
+// before its password is even checked.
+const (
+	maxScryptN      = 1 << 18
+	scryptR         = 8
+	maxScryptP      = 16
+	maxPbkdf2Rounds = 1000000
+)
+
+var (
+	ErrUnsupportedKdf = errors.New("unsupported keystore kdf")
+	ErrInvalidVersion = errors.New("keystore version must be 3")
+	ErrKdfParams      = errors.New("keystore kdf parameters out of range")
+)
+
+type keyJSON struct {
+	Address string          `json:"address"`
+	Crypto  json.RawMessage `json:"crypto"`
+	// MyEtherWallet writes "Crypto".
+	CryptoUpper json.RawMessage `json:"Crypto,omitempty"`
+	ID          string          `json:"id"`
+	Version     int             `json:"version"`
+}
+
+type cryptoJSON struct {
+	Cipher       string           `json:"cipher"`
+	CipherText   string           `json:"ciphertext"`
+	CipherParams cipherParamsJSON `json:"cipherparams"`
+	KDF          string           `json:"kdf"`
+	KDFParams    map[string]any   `json:"kdfparams"`
+	MAC          string           `json:"mac"`
+}
+
+type cipherParamsJSON struct {
+	IV string `json:"iv"`
+}
+
+// Encrypt serializes privateKey as a password protected keystore v3 file.
+// The address field is the Ethereum address as 40 lower case hex digits,
+// the only form other wallets accept, also for keys used on Tron.
+func Encrypt(privateKey *ecdsa.PrivateKey, password, kdf string) ([]byte, error) {
+	keyBytes := crypto.FromECDSA(privateKey)
+	defer secretbox.Zero(keyBytes)
+
+	var (
+		encrypted any
+		err       error
+	)
+	switch kdf {
+	case KdfScrypt, "":
+		encrypted, err = keystore.EncryptDataV3(keyBytes, []byte(password), keystore.StandardScryptN, keystore.StandardScryptP)
+	case KdfPbkdf2:
+		encrypted, err = encryptPbkdf2(keyBytes, []byte(password))
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedKdf, kdf)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	cryptoData, err := json.Marshal(encrypted)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(keyJSON{
+		Address: hex.EncodeToString(crypto.PubkeyToAddress(privateKey.PublicKey).Bytes()),
+		Crypto:  cryptoData,
+		ID:      uuid.NewString(),
+		Version: version,
+	})
+}
+
+// Decrypt opens a keystore v3 file encrypted with either scrypt or pbkdf2.
+func Decrypt(data []byte, password string) (*ecdsa.PrivateKey, error) {
+	var key keyJSON
+	if err := json.Unmarshal(data, &key); err != nil {
+		return nil, err
+	}
+	if key.Version != version {
+		return nil, ErrInvalidVersion
+	}
+	cryptoData := key.Crypto
+	if len(cryptoData) == 0 {
+		cryptoData = key.CryptoUpper
+	}
+
+	var encrypted keystore.CryptoJSON
+	if err := json.Unmarshal(cryptoData, &encrypted); err != nil {
+		return nil, err
+	}
+	if encrypted.KDF != KdfScrypt && encrypted.KDF != KdfPbkdf2 {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedKdf, encrypted.KDF)
+	}
+	if err := checkKdfParams(encrypted.KDF, encrypted.KDFParams); err != nil {
+		return nil, err
+	}
+
+	keyBytes, err := keystore.DecryptDataV3(encrypted, password)
+	if err != nil {
+		return nil, err
+	}
+	defer secretbox.Zero(keyBytes)
+	return crypto.ToECDSA(keyBytes)
+}
+
+// checkKdfParams rejects key derivation parameters beyond what standard
+// wallets write.
+func checkKdfParams(kdf string, params map[string]any) error {
+	if dklen := intParam(params, "dklen"); dklen != derivedKeyLen {
+		return fmt.Errorf("%w: dklen %d", ErrKdfParams, dklen)
+	}
+	switch kdf {
+	case KdfScrypt:
+		n, r, p := intParam(params, "n"), intParam(params, "r"), intParam(params, "p")
+		if n < 2 || n > maxScryptN || n&(n-1) != 0 || r != scryptR || p < 1 || p > maxScryptP {
+			return fmt.Errorf("%w: scrypt n=%d r=%d p=%d", ErrKdfParams, n, r, p)
+		}
+	case KdfPbkdf2:
+		c := intParam(params, "c")
+		if c < 1 || c > maxPbkdf2Rounds {
+			return fmt.Errorf("%w: pbkdf2 c=%d", ErrKdfParams, c)
+		}
+		if prf, _ := params["prf"].(string); prf != pbkdf2Prf {
+			return fmt.Errorf("%w: pbkdf2 prf %q", ErrKdfParams, prf)
+		}
+	}
+	return nil
+}
+
+// intParam reads the JSON number name of params, -1 when it is missing or
+// not a whole number.
+func intParam(params map[string]any, name string) int64 {
+	v, ok := params[name].(float64)
+	if !ok || v < 0 || v > math.MaxInt32 || v != math.Trunc(v) {
+		return -1
+	}
+	return int64(v)
+}
+
+func encryptPbkdf2(data, password []byte) (*cryptoJSON, error) {
+	salt := make([]byte, saltLen)
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return nil, err
+	}
+	iv := make([]byte, aes.BlockSize)
+	if _, err := io.ReadFull(rand.Reader, iv); err != nil {
+		return nil, err
+	}
+
+	derivedKey := pbkdf2.Key(password, salt, pbkdf2Rounds, derivedKeyLen, sha256.New)
+	defer secretbox.Zero(derivedKey)
+
+	block, err := aes.NewCipher(derivedKey[:16])
+	if err != nil {
+		return nil, err
+	}
+	cipherText := make([]byte, len(data))
+	cipher.NewCTR(block, iv).XORKeyStream(cipherText, data)
+	mac := crypto.Keccak256(derivedKey[16:32], cipherText)
+
+	return &cryptoJSON{
+		Cipher:       "aes-128-ctr",
+		CipherText:   hex.EncodeToString(cipherText),
+		CipherParams: cipherParamsJSON{IV: hex.EncodeToString(iv)},
+		KDF:          KdfPbkdf2,
+		KDFParams: map[string]any{
+			"c":     pbkdf2Rounds,
+			"dklen": derivedKeyLen,
+			"prf":   pbkdf2Prf,
+			"salt":  hex.EncodeToString(salt),
+		},
+		MAC: hex.EncodeToString(mac),
+	}, nil
+}
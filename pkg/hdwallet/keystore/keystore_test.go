@@ -0,0 +1,100 @@
+package keystore
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	gethkeystore "github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func TestEncryptDecrypt(t *testing.T) {
+	privateKey, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	address := crypto.PubkeyToAddress(privateKey.PublicKey)
+
+	for _, kdf := range []string{KdfScrypt, KdfPbkdf2} {
+		data, err := Encrypt(privateKey, "secret", kdf)
+		if err != nil {
+			t.Fatalf("%s: %v", kdf, err)
+		}
+		var key keyJSON
+		if err = json.Unmarshal(data, &key); err != nil {
+			t.Fatal(err)
+		}
+		if want := hex.EncodeToString(address.Bytes()); key.Address != want {
+			t.Errorf("%s: address %q, want %q", kdf, key.Address, want)
+		}
+		// geth parses the address field and rejects anything but hex.
+		gethKey, err := gethkeystore.DecryptKey(data, "secret")
+		if err != nil {
+			t.Fatalf("%s: geth decrypt: %v", kdf, err)
+		}
+		if gethKey.Address != address {
+			t.Errorf("%s: geth address %s", kdf, gethKey.Address)
+		}
+
+		decrypted, err := Decrypt(data, "secret")
+		if err != nil {
+			t.Fatalf("%s: %v", kdf, err)
+		}
+		if decrypted.D.Cmp(privateKey.D) != 0 {
+			t.Errorf("%s: decrypted key mismatch", kdf)
+		}
+		if _, err = Decrypt(data, "wrong"); err == nil {
+			t.Errorf("%s: expected wrong password to fail", kdf)
+		}
+	}
+}
+
+func TestDecryptWeb3Vector(t *testing.T) {
+	// pbkdf2 test vector of the Web3 Secret Storage definition.
+	data := `{"crypto":{"cipher":"aes-128-ctr","cipherparams":{"iv":"6087dab2f9fdbbfaddc31a909735c1e6"},"ciphertext":"5318b4d5bcd28de64ee5559e671353e16f075ecae9f99c7a79a38af5f869aa46","kdf":"pbkdf2","kdfparams":{"c":262144,"dklen":32,"prf":"hmac-sha256","salt":"ae3cd4e7013836a3df6bd7241b12db061dbe2c6785853cce422d148a624ce0bd"},"mac":"517ead924a9d0dc3124507e3393d175ce3ff7c1e96529c6c555ce9e51205e9b2"},"id":"3198bc9c-6672-5ab3-d995-4942343ae5b6","version":3}`
+	privateKey, err := Decrypt([]byte(data), "testpassword")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := hex.EncodeToString(crypto.FromECDSA(privateKey)); got != "7a28b5ba57c53603b0b07b56bba752f7784bf506fa95edc395f5cf6c7514fe9d" {
+		t.Errorf("private key = %s", got)
+	}
+}
+
+func TestDecryptKdfLimits(t *testing.T) {
+	privateKey, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		kdf   string
+		name  string
+		value any
+	}{
+		{KdfScrypt, "n", 1 << 30},
+		{KdfScrypt, "n", 3 << 10},
+		{KdfScrypt, "r", 1024},
+		{KdfScrypt, "p", 1 << 20},
+		{KdfScrypt, "dklen", 1 << 30},
+		{KdfPbkdf2, "c", 100000000},
+		{KdfPbkdf2, "prf", "hmac-sha512"},
+	} {
+		data, err := Encrypt(privateKey, "secret", tc.kdf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var key map[string]any
+		if err = json.Unmarshal(data, &key); err != nil {
+			t.Fatal(err)
+		}
+		key["crypto"].(map[string]any)["kdfparams"].(map[string]any)[tc.name] = tc.value
+		if data, err = json.Marshal(key); err != nil {
+			t.Fatal(err)
+		}
+		if _, err = Decrypt(data, "secret"); !errors.Is(err, ErrKdfParams) {
+			t.Errorf("%s %s=%v: expected ErrKdfParams, got %v", tc.kdf, tc.name, tc.value, err)
+		}
+	}
+}
@@ -0,0 +1,171 @@
+package hdwallet
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+
+	"github.com/tyler-smith/go-bip39/wordlists"
+	"golang.org/x/text/unicode/norm"
+)
+
+const (
+	LanguageEnglish            = "english"
+	LanguageJapanese           = "japanese"
+	LanguageKorean             = "korean"
+	LanguageChineseSimplified  = "chinese_simplified"
+	LanguageChineseTraditional = "chinese_traditional"
+	LanguageFrench             = "french"
+	LanguageItalian            = "italian"
+	LanguageSpanish            = "spanish"
+	LanguageCzech              = "czech"
+)
+
+const (
+	DefaultMnemonicWords = 12
+	ideographicSpace     = "　"
+)
+
+var (
+	ErrUnsupportedLanguage = errors.New("unsupported mnemonic language")
+	ErrInvalidWordCount    = errors.New("mnemonic word count must be 12, 15, 18, 21 or 24")
+	ErrInvalidMnemonic     = errors.New("mnemonic is invalid")
+)
+
+// Languages lists the BIP39 wordlists in the order they are tried when
+// detecting the language of a mnemonic.
+var Languages = []string{
+	LanguageEnglish,
+	LanguageJapanese,
+	LanguageKorean,
+	LanguageChineseSimplified,
+	LanguageChineseTraditional,
+	LanguageFrench,
+	LanguageItalian,
+	LanguageSpanish,
+	LanguageCzech,
+}
+
+var wordLists = map[string][]string{
+	LanguageEnglish:            wordlists.English,
+	LanguageJapanese:           wordlists.Japanese,
+	LanguageKorean:             wordlists.Korean,
+	LanguageChineseSimplified:  wordlists.ChineseSimplified,
+	LanguageChineseTraditional: wordlists.ChineseTraditional,
+	LanguageFrench:             wordlists.French,
+	LanguageItalian:            wordlists.Italian,
+	LanguageSpanish:            wordlists.Spanish,
+	LanguageCzech:              wordlists.Czech,
+}
+
+var (
+	wordIndexOnce sync.Once
+	wordIndexes   map[string]map[string]int
+)
+
+// wordIndex returns the NFKD normalized word -> index map of a language.
+func wordIndex(language string) map[string]int {
+	wordIndexOnce.Do(func() {
+		wordIndexes = make(map[string]map[string]int, len(wordLists))
+		for lang, list := range wordLists {
+			index := make(map[string]int, len(list))
+			for i, word := range list {
+				index[norm.NFKD.String(word)] = i
+			}
+			wordIndexes[lang] = index
+		}
+	})
+	return wordIndexes[language]
+}
+
+// MnemonicEntropyBits converts a word count into the BIP39 entropy size.
+func MnemonicEntropyBits(wordCount int) (int, error) {
+	switch wordCount {
+	case 12, 15, 18, 21, 24:
+		return wordCount * 32 / 3, nil
+	default:
+		return 0, ErrInvalidWordCount
+	}
+}
+
+func encodeMnemonic(entropy []byte, language string) (string, error) {
+	list, ok := wordLists[language]
+	if !ok {
+		return "", ErrUnsupportedLanguage
+	}
+
+	checksumBits := len(entropy) / 4
+	hash := sha256.Sum256(entropy)
+	data := new(big.Int).SetBytes(entropy)
+	data.Lsh(data, uint(checksumBits))
+	data.Or(data, big.NewInt(int64(hash[0]>>(8-checksumBits))))
+
+	wordCount := (len(entropy)*8 + checksumBits) / 11
+	words := make([]string, wordCount)
+	mask := big.NewInt(2047)
+	for i := wordCount - 1; i >= 0; i-- {
+		words[i] = list[new(big.Int).And(data, mask).Int64()]
+		data.Rsh(data, 11)
+	}
+
+	separator := " "
+	if language == LanguageJapanese {
+		separator = ideographicSpace
+	}
+	return strings.Join(words, separator), nil
+}
+
+// decodeMnemonic returns the entropy of mnemonic when every word belongs to
+// language and the checksum matches.
+func decodeMnemonic(words []string, language string) ([]byte, bool) {
+	index := wordIndex(language)
+	if index == nil {
+		return nil, false
+	}
+
+	data := new(big.Int)
+	for _, word := range words {
+		i, ok := index[word]
+		if !ok {
+			return nil, false
+		}
+		data.Lsh(data, 11)
+		data.Or(data, big.NewInt(int64(i)))
+	}
+
+	checksumBits := len(words) * 11 / 33
+	checksum := new(big.Int).And(data, big.NewInt(int64(1<<checksumBits-1)))
+	data.Rsh(data, uint(checksumBits))
+
+	entropy := make([]byte, (len(words)*11-checksumBits)/8)
+	data.FillBytes(entropy)
+	hash := sha256.Sum256(entropy)
+	if int64(hash[0]>>(8-checksumBits)) != checksum.Int64() {
+		return nil, false
+	}
+	return entropy, true
+}
+
+// DetectMnemonicLanguage validates mnemonic against every supported wordlist
+// and returns the first language whose words and checksum match.
+func DetectMnemonicLanguage(mnemonic string) (string, error) {
+	words := strings.Fields(norm.NFKD.String(mnemonic))
+	if _, err := MnemonicEntropyBits(len(words)); err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidMnemonic, err)
+	}
+	for _, language := range Languages {
+		if _, ok := decodeMnemonic(words, language); ok {
+			return language, nil
+		}
+	}
+	return "", ErrInvalidMnemonic
+}
+
+// IsMnemonicValid reports whether mnemonic is valid in any supported language.
+func IsMnemonicValid(mnemonic string) bool {
+	_, err := DetectMnemonicLanguage(mnemonic)
+	return err == nil
+}
@@ -0,0 +1,103 @@
+package hdwallet
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/tyler-smith/go-bip32"
+)
+
+const bip44Purpose = 44
+
+var (
+	ErrInvalidDerivationPath = errors.New("invalid derivation path")
+	ErrIndexOutOfRange       = errors.New("index must be below 2^31")
+)
+
+// DerivationPath is a list of child indexes from the master key, hardened
+// indexes carry the bip32.FirstHardenedChild offset.
+type DerivationPath []uint32
+
+// DefaultDerivationPath returns m/44'/coinType'/0'/0/0, the path every
+// address was derived with before paths became configurable.
+func DefaultDerivationPath(coinType uint32) DerivationPath {
+	return NewDerivationPath(coinType, 0, 0, 0)
+}
+
+// NewDerivationPath returns the BIP44 path m/44'/coinType'/account'/change/index.
+func NewDerivationPath(coinType, account, change, index uint32) DerivationPath {
+	return DerivationPath{
+		bip32.FirstHardenedChild + bip44Purpose,
+		bip32.FirstHardenedChild + coinType,
+		bip32.FirstHardenedChild + account,
+		change,
+		index,
+	}
+}
+
+// ParseDerivationPath parses an absolute path such as m/44'/195'/0'/0/12.
+// Hardened indexes may be marked with ' or h.
+func ParseDerivationPath(path string) (DerivationPath, error) {
+	components := strings.Split(strings.TrimSpace(path), "/")
+	if len(components) < 2 || components[0] != "m" {
+		return nil, fmt.Errorf("%w: %q must start with m/", ErrInvalidDerivationPath, path)
+	}
+
+	result := make(DerivationPath, 0, len(components)-1)
+	for _, component := range components[1:] {
+		var offset uint32
+		if strings.HasSuffix(component, "'") || strings.HasSuffix(component, "h") {
+			offset = bip32.FirstHardenedChild
+			component = component[:len(component)-1]
+		}
+		value, err := strconv.ParseUint(component, 10, 32)
+		if err != nil || uint32(value) >= bip32.FirstHardenedChild {
+			return nil, fmt.Errorf("%w: bad component %q in %q", ErrInvalidDerivationPath, component, path)
+		}
+		result = append(result, uint32(value)+offset)
+	}
+	return result, nil
+}
+
+// CheckCoinType makes sure p is a BIP44 path of coinType, so a path sent
+// along with a request cannot derive the key of another network.
+func (p DerivationPath) CheckCoinType(coinType uint32) error {
+	if len(p) < 2 || p[0] != bip32.FirstHardenedChild+bip44Purpose {
+		return fmt.Errorf("%w: %s is not a BIP44 path", ErrInvalidDerivationPath, p)
+	}
+	if p[1] != bip32.FirstHardenedChild+coinType {
+		return fmt.Errorf("%w: %s is not a path of coin type %d", ErrInvalidDerivationPath, p, coinType)
+	}
+	return nil
+}
+
+// Derive walks p from masterKey. Private parents derive private children,
+// public parents only non-hardened ones.
+func (p DerivationPath) Derive(masterKey *bip32.Key) (*bip32.Key, error) {
+	key := masterKey
+	for depth, index := range p {
+		child, err := key.NewChildKey(index)
+		if err != nil {
+			return nil, fmt.Errorf("failed to derive %s at depth %d: %v", p, depth+1, err)
+		}
+		key = child
+	}
+	return key, nil
+}
+
+func (p DerivationPath) String() string {
+	var builder strings.Builder
+	builder.WriteString("m")
+	for _, component := range p {
+		builder.WriteString("/")
+		if component >= bip32.FirstHardenedChild {
+			builder.WriteString(strconv.FormatUint(uint64(component-bip32.FirstHardenedChild), 10))
+			builder.WriteString("'")
+		} else {
+			builder.WriteString(strconv.FormatUint(uint64(component), 10))
+		}
+	}
+	return builder.String()
+}
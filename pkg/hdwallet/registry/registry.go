@@ -0,0 +1,118 @@
+package registry
+
+import (
+	"errors"
+	"strings"
+	"sync"
+)
+
+var ErrUnknownToken = errors.New("unknown token")
+
+// Token is a registered token, Decimals is read from the contract the first
+// time the token is resolved.
+type Token struct {
+	Symbol   string `json:"symbol"`
+	Contract string `json:"contract"`
+	Decimals int    `json:"decimals"`
+}
+
+// Tokens holds the tokens a chain is configured with, keyed by upper case
+// symbol and by contract. The maps are fixed once built, mu only guards the
+// decimals filled in lazily and is never held across a chain call.
+type Tokens struct {
+	mu         sync.Mutex
+	bySymbol   map[string]*entry
+	byContract map[string]*entry
+	// canonical maps a contract to the form it is keyed by, nil keeps it
+	// as given.
+	canonical func(contract string) string
+}
+
+type entry struct {
+	token Token
+	// load serialises reading the decimals of this token only, a slow
+	// node does not hold up the other tokens.
+	load   sync.Mutex
+	loaded bool
+}
+
+func NewTokens(canonical func(contract string) string) *Tokens {
+	if canonical == nil {
+		canonical = func(contract string) string { return contract }
+	}
+	return &Tokens{
+		bySymbol:   make(map[string]*entry),
+		byContract: make(map[string]*entry),
+		canonical:  canonical,
+	}
+}
+
+// Add registers contract under symbol. Tokens are added before the registry
+// is shared, Add is not safe for concurrent use.
+func (r *Tokens) Add(symbol, contract string) {
+	e := &entry{token: Token{Symbol: strings.ToUpper(symbol), Contract: r.canonical(contract)}}
+	r.bySymbol[e.token.Symbol] = e
+	r.byContract[e.token.Contract] = e
+}
+
+// Has reports whether symbol is registered.
+func (r *Tokens) Has(symbol string) bool {
+	_, ok := r.bySymbol[strings.ToUpper(symbol)]
+	return ok
+}
+
+func (r *Tokens) lookup(key string) *entry {
+	if e := r.byContract[r.canonical(key)]; e != nil {
+		return e
+	}
+	return r.bySymbol[strings.ToUpper(key)]
+}
+
+func (r *Tokens) snapshot(e *entry) (Token, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return e.token, e.loaded
+}
+
+// Resolve returns the token of key, a symbol or a contract, reading its
+// decimals with loadDecimals the first time. A failed read is retried by the
+// next call.
+func (r *Tokens) Resolve(key string, loadDecimals func(contract string) (int, error)) (*Token, error) {
+	e := r.lookup(key)
+	if e == nil {
+		return nil, ErrUnknownToken
+	}
+	if token, loaded := r.snapshot(e); loaded {
+		return &token, nil
+	}
+
+	e.load.Lock()
+	defer e.load.Unlock()
+	if token, loaded := r.snapshot(e); loaded {
+		return &token, nil
+	}
+	decimals, err := loadDecimals(e.token.Contract)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	e.token.Decimals = decimals
+	e.loaded = true
+	token := e.token
+	return &token, nil
+}
+
+// List returns the registered tokens without touching the chain, the
+// decimals of a token not resolved yet are zero.
+func (r *Tokens) List() []Token {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	list := make([]Token, 0, len(r.bySymbol))
+	for _, e := range r.bySymbol {
+		list = append(list, e.token)
+	}
+	return list
+}
@@ -0,0 +1,67 @@
+package registry
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestTokensResolve(t *testing.T) {
+	r := NewTokens(strings.ToLower)
+	r.Add("dai", "0xAbC")
+	r.Add("usdt", "0xDeF")
+
+	var (
+		mu    sync.Mutex
+		calls = make(map[string]int)
+		fail  = true
+	)
+	loadDecimals := func(contract string) (int, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		calls[contract]++
+		if contract == "0xdef" && fail {
+			return 0, errors.New("node down")
+		}
+		return 18, nil
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := r.Resolve("DAI", loadDecimals); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if calls["0xabc"] != 1 {
+		t.Errorf("decimals read %d times, want once", calls["0xabc"])
+	}
+	token, err := r.Resolve("0xABC", loadDecimals)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *token != (Token{Symbol: "DAI", Contract: "0xabc", Decimals: 18}) {
+		t.Errorf("token by contract %+v", token)
+	}
+
+	// A failed read is not cached.
+	if _, err = r.Resolve("USDT", loadDecimals); err == nil {
+		t.Error("expected the decimals error")
+	}
+	fail = false
+	if token, err = r.Resolve("usdt", loadDecimals); err != nil || token.Decimals != 18 {
+		t.Errorf("usdt %+v, %v", token, err)
+	}
+
+	if _, err = r.Resolve("TRX", loadDecimals); err != ErrUnknownToken {
+		t.Errorf("expected ErrUnknownToken, got %v", err)
+	}
+	if !r.Has("Dai") || r.Has("TRX") || len(r.List()) != 2 {
+		t.Errorf("registered %v", r.List())
+	}
+}
@@ -0,0 +1,191 @@
+package hdwallet
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/text/unicode/norm"
+	"wallet/pkg/tools/secretbox"
+)
+
+// Shares are Shamir secret sharing over GF(256) of the mnemonic entropy, so
+// any threshold of them rebuild the exact mnemonic and passphrase handling
+// stays unchanged. A share is hex encoded as
+//
+//	version | threshold | index | language | entropy share | sha256 checksum[:4]
+const (
+	shareVersion       = 1
+	shareHeaderLength  = 4
+	shareChecksumBytes = 4
+)
+
+var (
+	ErrInvalidThreshold = errors.New("threshold must be at least 2 and at most the share count, share count at most 255")
+	ErrInvalidShare     = errors.New("share is invalid")
+	ErrShareMismatch    = errors.New("shares do not belong to the same split")
+	ErrNotEnoughShares  = errors.New("not enough shares to reach the threshold")
+)
+
+// SplitMnemonic splits mnemonic into count shares of which any threshold
+// recover it.
+func SplitMnemonic(mnemonic string, threshold, count int) ([]string, error) {
+	if threshold < 2 || threshold > count || count > 255 {
+		return nil, ErrInvalidThreshold
+	}
+	language, err := DetectMnemonicLanguage(mnemonic)
+	if err != nil {
+		return nil, err
+	}
+	entropy, _ := decodeMnemonic(strings.Fields(norm.NFKD.String(mnemonic)), language)
+	defer secretbox.Zero(entropy)
+
+	// coefficients[i] is the random polynomial of entropy byte i, its
+	// constant term is the secret byte itself.
+	coefficients := make([][]byte, len(entropy))
+	for i, secret := range entropy {
+		coefficients[i] = make([]byte, threshold)
+		coefficients[i][0] = secret
+		if _, err = rand.Read(coefficients[i][1:]); err != nil {
+			return nil, err
+		}
+	}
+	defer func() {
+		for _, c := range coefficients {
+			secretbox.Zero(c)
+		}
+	}()
+
+	shares := make([]string, count)
+	for n := 0; n < count; n++ {
+		x := byte(n + 1)
+		data := make([]byte, len(entropy))
+		for i := range entropy {
+			data[i] = evaluatePolynomial(coefficients[i], x)
+		}
+		shares[n] = encodeShare(byte(threshold), x, languageIndex(language), data)
+	}
+	return shares, nil
+}
+
+// CombineMnemonic rebuilds the mnemonic from at least threshold shares of
+// one split. A wrong combination of shares still yields a valid looking
+// mnemonic, callers must check the derived addresses against known ones.
+func CombineMnemonic(shares []string) (string, error) {
+	var (
+		threshold, language byte
+		xs                  []byte
+		ys                  [][]byte
+	)
+	for n, s := range shares {
+		share, err := decodeShare(s)
+		if err != nil {
+			return "", fmt.Errorf("share %d: %w", n+1, err)
+		}
+		if n == 0 {
+			threshold, language = share.threshold, share.language
+		} else if share.threshold != threshold || share.language != language || len(share.data) != len(ys[0]) {
+			return "", ErrShareMismatch
+		}
+		if bytes.IndexByte(xs, share.index) >= 0 {
+			// The same share given twice counts once.
+			continue
+		}
+		xs = append(xs, share.index)
+		ys = append(ys, share.data)
+	}
+	if len(xs) == 0 || threshold < 2 || len(xs) < int(threshold) {
+		return "", ErrNotEnoughShares
+	}
+	xs, ys = xs[:threshold], ys[:threshold]
+
+	entropy := make([]byte, len(ys[0]))
+	defer secretbox.Zero(entropy)
+	for j := range xs {
+		// Lagrange basis of share j at x = 0, subtraction is xor in GF(256).
+		basis := byte(1)
+		for m := range xs {
+			if m != j {
+				basis = gfMul(basis, gfMul(xs[m], gfInv(xs[j]^xs[m])))
+			}
+		}
+		for i := range entropy {
+			entropy[i] ^= gfMul(ys[j][i], basis)
+		}
+	}
+	return encodeMnemonic(entropy, Languages[language])
+}
+
+type share struct {
+	threshold, index, language byte
+	data                       []byte
+}
+
+func encodeShare(threshold, index, language byte, data []byte) string {
+	raw := append([]byte{shareVersion, threshold, index, language}, data...)
+	checksum := sha256.Sum256(raw)
+	return hex.EncodeToString(append(raw, checksum[:shareChecksumBytes]...))
+}
+
+func decodeShare(s string) (*share, error) {
+	raw, err := hex.DecodeString(strings.TrimSpace(s))
+	if err != nil || len(raw) < shareHeaderLength+shareChecksumBytes {
+		return nil, ErrInvalidShare
+	}
+	body, checksum := raw[:len(raw)-shareChecksumBytes], raw[len(raw)-shareChecksumBytes:]
+	sum := sha256.Sum256(body)
+	if !bytes.Equal(sum[:shareChecksumBytes], checksum) {
+		return nil, fmt.Errorf("%w: checksum mismatch", ErrInvalidShare)
+	}
+
+	out := &share{threshold: body[1], index: body[2], language: body[3], data: body[shareHeaderLength:]}
+	if body[0] != shareVersion || out.threshold < 2 || out.index == 0 || int(out.language) >= len(Languages) {
+		return nil, ErrInvalidShare
+	}
+	if _, err = MnemonicEntropyBits(len(out.data) * 8 * 3 / 32); err != nil || len(out.data)%4 != 0 {
+		return nil, ErrInvalidShare
+	}
+	return out, nil
+}
+
+func languageIndex(language string) byte {
+	for i, l := range Languages {
+		if l == language {
+			return byte(i)
+		}
+	}
+	return 0
+}
+
+func evaluatePolynomial(coefficients []byte, x byte) byte {
+	var y byte
+	for i := len(coefficients) - 1; i >= 0; i-- {
+		y = gfMul(y, x) ^ coefficients[i]
+	}
+	return y
+}
+
+// gfMul multiplies in GF(2^8) modulo x^8 + x^4 + x^3 + x + 1 without
+// secret dependent branches.
+func gfMul(a, b byte) byte {
+	var p byte
+	for i := 0; i < 8; i++ {
+		p ^= -(b & 1) & a
+		a = (a << 1) ^ (-(a >> 7) & 0x1b)
+		b >>= 1
+	}
+	return p
+}
+
+// gfInv returns a^254, the multiplicative inverse of a non-zero a.
+func gfInv(a byte) byte {
+	result := byte(1)
+	for i := 0; i < 254; i++ {
+		result = gfMul(result, a)
+	}
+	return result
+}
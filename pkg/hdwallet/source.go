@@ -0,0 +1,62 @@
+package hdwallet
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/tyler-smith/go-bip32"
+)
+
+var ErrInvalidPrivateKey = errors.New("invalid secp256k1 private key")
+
+// KeySource resolves the private key that signs for a derivation path. A
+// master key derives path, a raw private key has a single key for every path.
+type KeySource interface {
+	PrivateKey(path DerivationPath) (*ecdsa.PrivateKey, error)
+}
+
+type masterKeySource struct {
+	masterKey *bip32.Key
+}
+
+// NewMasterKeySource wraps the master key of a mnemonic or stored seed.
+func NewMasterKeySource(masterKey *bip32.Key) KeySource {
+	return masterKeySource{masterKey: masterKey}
+}
+
+func (s masterKeySource) PrivateKey(path DerivationPath) (*ecdsa.PrivateKey, error) {
+	return DerivePrivateKeyByPath(s.masterKey, path)
+}
+
+type privateKeySource struct {
+	privateKey *ecdsa.PrivateKey
+}
+
+// NewPrivateKeySource wraps a raw secp256k1 key, the same key is used for the
+// Tron and the Ethereum address.
+func NewPrivateKeySource(privateKey *ecdsa.PrivateKey) KeySource {
+	return privateKeySource{privateKey: privateKey}
+}
+
+// NewPrivateKeySourceFromHex parses a 32 byte hex key with or without 0x.
+func NewPrivateKeySourceFromHex(hexKey string) (KeySource, error) {
+	privateKey, err := ParsePrivateKey(hexKey)
+	if err != nil {
+		return nil, err
+	}
+	return NewPrivateKeySource(privateKey), nil
+}
+
+func ParsePrivateKey(hexKey string) (*ecdsa.PrivateKey, error) {
+	privateKey, err := crypto.HexToECDSA(strings.TrimPrefix(strings.TrimSpace(hexKey), "0x"))
+	if err != nil {
+		return nil, ErrInvalidPrivateKey
+	}
+	return privateKey, nil
+}
+
+func (s privateKeySource) PrivateKey(DerivationPath) (*ecdsa.PrivateKey, error) {
+	return s.privateKey, nil
+}
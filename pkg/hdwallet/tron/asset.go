@@ -0,0 +1,33 @@
+package tron
+
+import "errors"
+
+var ErrUnknownAsset = errors.New("unknown trc10 asset")
+
+// Trc10Asset is the metadata of a TRC-10 asset, amounts are integers scaled
+// by 10^Precision.
+type Trc10Asset struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Abbr        string `json:"abbr"`
+	Precision   int    `json:"precision"`
+	TotalSupply int64  `json:"total_supply"`
+}
+
+// GetTrc10Asset looks up the asset issued under assetID.
+func GetTrc10Asset(assetID string) (*Trc10Asset, error) {
+	asset, err := Client.GetAssetIssueByID(assetID)
+	if err != nil {
+		return nil, err
+	}
+	if asset == nil {
+		return nil, ErrUnknownAsset
+	}
+	return &Trc10Asset{
+		ID:          asset.Id,
+		Name:        string(asset.Name),
+		Abbr:        string(asset.Abbr),
+		Precision:   int(asset.Precision),
+		TotalSupply: asset.TotalSupply,
+	}, nil
+}
@@ -0,0 +1,162 @@
+package tron
+
+import (
+	"errors"
+	"math"
+	"math/big"
+
+	"github.com/fbsobreira/gotron-sdk/pkg/proto/api"
+	"wallet/pkg/common/config"
+)
+
+// Sizes in bytes of signed transfers, the bandwidth they consume.
+const (
+	trxTransferBandwidth   = 270
+	trc10TransferBandwidth = 290
+	trc20TransferBandwidth = 350
+)
+
+const (
+	defaultEnergyMultiplier = 1.2
+	defaultFeeLimitCap      = 100000000
+)
+
+var (
+	ErrSelfTransfer      = errors.New("sender and receiver are the same address")
+	ErrFeeLimitExceedCap = errors.New("estimated fee limit exceeds the configured cap")
+)
+
+// TransferFee is the most TRX, in sun, a transfer may burn on top of the
+// amount sent.
+type TransferFee struct {
+	Bandwidth int64 `json:"bandwidth"`
+	// Energy is what a contract call was estimated to consume, FeeLimit is
+	// the energy cost the transaction is allowed, both zero for transfers
+	// that run no contract.
+	Energy   int64 `json:"energy,omitempty"`
+	FeeLimit int64 `json:"fee_limit,omitempty"`
+	// Activation is set when the receiver is not activated yet, the
+	// transfer then pays the account creation fees.
+	Activation bool  `json:"activation"`
+	Fee        int64 `json:"fee"`
+}
+
+// Resources is the energy and bandwidth an account may use before TRX is
+// burnt instead.
+type Resources struct {
+	EnergyLimit        int64 `json:"energy_limit"`
+	EnergyUsed         int64 `json:"energy_used"`
+	EnergyAvailable    int64 `json:"energy_available"`
+	FreeBandwidthLimit int64 `json:"free_bandwidth_limit"`
+	FreeBandwidthUsed  int64 `json:"free_bandwidth_used"`
+	BandwidthLimit     int64 `json:"bandwidth_limit"`
+	BandwidthUsed      int64 `json:"bandwidth_used"`
+	BandwidthAvailable int64 `json:"bandwidth_available"`
+}
+
+func GetResources(address string) (*Resources, error) {
+	resource, err := Client.GetAccountResource(address)
+	if err != nil {
+		return nil, err
+	}
+	return newResources(resource), nil
+}
+
+func newResources(resource *api.AccountResourceMessage) *Resources {
+	return &Resources{
+		EnergyLimit:        resource.EnergyLimit,
+		EnergyUsed:         resource.EnergyUsed,
+		EnergyAvailable:    max(resource.EnergyLimit-resource.EnergyUsed, 0),
+		FreeBandwidthLimit: resource.FreeNetLimit,
+		FreeBandwidthUsed:  resource.FreeNetUsed,
+		BandwidthLimit:     resource.NetLimit,
+		BandwidthUsed:      resource.NetUsed,
+		BandwidthAvailable: max(resource.FreeNetLimit-resource.FreeNetUsed, 0) + max(resource.NetLimit-resource.NetUsed, 0),
+	}
+}
+
+// EstimateTrxTransferFee prices a TRX transfer from the chain parameters and
+// the bandwidth the sender has left.
+func EstimateTrxTransferFee(senderAddress, receiverAddress string) (*TransferFee, error) {
+	return estimateTransferFee(senderAddress, receiverAddress, trxTransferBandwidth)
+}
+
+// EstimateTrc10TransferFee prices a TRC-10 transfer the same way, it burns
+// no energy.
+func EstimateTrc10TransferFee(senderAddress, receiverAddress string) (*TransferFee, error) {
+	return estimateTransferFee(senderAddress, receiverAddress, trc10TransferBandwidth)
+}
+
+func estimateTransferFee(senderAddress, receiverAddress string, bandwidth int64) (*TransferFee, error) {
+	params, err := Client.GetChainParameters()
+	if err != nil {
+		return nil, err
+	}
+	resource, err := Client.GetAccountResource(senderAddress)
+	if err != nil {
+		return nil, err
+	}
+	receiver, err := Client.GetAccount(receiverAddress)
+	if err != nil {
+		return nil, err
+	}
+	return priceTransfer(params, newResources(resource), bandwidth, 0, receiver == nil)
+}
+
+// EstimateTrc20TransferFee dry runs the transfer to learn the energy it
+// needs and prices it, FeeLimit is the estimated energy cost with the
+// configured margin.
+func EstimateTrc20TransferFee(senderAddress string, token *TokenInfo, receiverAddress string, amount *big.Int) (*TransferFee, error) {
+	params, err := Client.GetChainParameters()
+	if err != nil {
+		return nil, err
+	}
+	resource, err := Client.GetAccountResource(senderAddress)
+	if err != nil {
+		return nil, err
+	}
+	energy, err := Client.EstimateTrc20TransferEnergy(senderAddress, receiverAddress, token.Contract, amount)
+	if err != nil {
+		return nil, err
+	}
+	return priceTransfer(params, newResources(resource), trc20TransferBandwidth, energy, false)
+}
+
+// priceTransfer works out what a transaction burns. Bandwidth is burnt at
+// getTransactionFee per byte once free and staked bandwidth run out, energy
+// at getEnergyFee per unit beyond the staked energy. Activating a receiver
+// costs getCreateNewAccountFeeInSystemContract, plus getCreateAccountFee
+// unless staked bandwidth covers it, free bandwidth never does.
+func priceTransfer(params map[string]int64, resources *Resources, bandwidth, energy int64, activation bool) (*TransferFee, error) {
+	fee := &TransferFee{Bandwidth: bandwidth, Energy: energy, Activation: activation}
+	staked := max(resources.BandwidthLimit-resources.BandwidthUsed, 0)
+	if activation {
+		fee.Fee = params["getCreateNewAccountFeeInSystemContract"]
+		if staked < bandwidth {
+			fee.Fee += params["getCreateAccountFee"]
+		}
+	} else if resources.BandwidthAvailable < bandwidth {
+		fee.Fee = bandwidth * params["getTransactionFee"]
+	}
+	if energy == 0 {
+		return fee, nil
+	}
+
+	feeConfig := config.Config.Blockchain.TronFee
+	multiplier := feeConfig.EnergyMultiplier
+	if multiplier == 0 {
+		multiplier = defaultEnergyMultiplier
+	}
+	feeLimitCap := feeConfig.FeeLimitCap
+	if feeLimitCap == 0 {
+		feeLimitCap = defaultFeeLimitCap
+	}
+
+	energyFee := params["getEnergyFee"]
+	if energy*energyFee > feeLimitCap {
+		return nil, ErrFeeLimitExceedCap
+	}
+	fee.FeeLimit = min(int64(math.Ceil(float64(energy*energyFee)*multiplier)), feeLimitCap)
+	fee.Fee += max(energy-resources.EnergyAvailable, 0) * energyFee
+	return fee, nil
+}
@@ -0,0 +1,42 @@
+package tron
+
+import "testing"
+
+var testChainParams = map[string]int64{
+	"getTransactionFee":                      1000,
+	"getEnergyFee":                           420,
+	"getCreateAccountFee":                    100000,
+	"getCreateNewAccountFeeInSystemContract": 1000000,
+}
+
+func TestPriceTransfer(t *testing.T) {
+	tests := []struct {
+		name       string
+		resources  Resources
+		bandwidth  int64
+		energy     int64
+		activation bool
+		fee        int64
+		feeLimit   int64
+	}{
+		{"free bandwidth", Resources{BandwidthAvailable: 600}, 270, 0, false, 0, 0},
+		{"burn bandwidth", Resources{BandwidthAvailable: 100}, 270, 0, false, 270000, 0},
+		{"activation", Resources{BandwidthAvailable: 600}, 270, 0, true, 1100000, 0},
+		{"activation staked", Resources{BandwidthLimit: 1000, BandwidthAvailable: 1000}, 270, 0, true, 1000000, 0},
+		{"burn energy", Resources{BandwidthAvailable: 600}, 350, 30000, false, 12600000, 15120000},
+		{"staked energy", Resources{BandwidthAvailable: 600, EnergyAvailable: 20000}, 350, 30000, false, 4200000, 15120000},
+	}
+	for _, tt := range tests {
+		fee, err := priceTransfer(testChainParams, &tt.resources, tt.bandwidth, tt.energy, tt.activation)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if fee.Fee != tt.fee || fee.FeeLimit != tt.feeLimit {
+			t.Errorf("%s: fee %d fee limit %d, want %d and %d", tt.name, fee.Fee, fee.FeeLimit, tt.fee, tt.feeLimit)
+		}
+	}
+
+	if _, err := priceTransfer(testChainParams, &Resources{}, 350, 1000000, false); err != ErrFeeLimitExceedCap {
+		t.Errorf("expected ErrFeeLimitExceedCap, got %v", err)
+	}
+}
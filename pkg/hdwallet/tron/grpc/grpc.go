@@ -1,6 +1,7 @@
 package grpcs
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -17,6 +18,12 @@ import (
 	"google.golang.org/grpc"
 )
 
+const trc20TransferMethod = "transfer(address,uint256)"
+
+// ErrDuplicateTransaction is returned when the node already holds the
+// broadcast transaction.
+var ErrDuplicateTransaction = errors.New("transaction already broadcast")
+
 type Client struct {
 	node string
 	GRPC *client.GrpcClient
@@ -67,21 +74,80 @@ func (c *Client) Transfer(from, to string, amount int64) (*api.TransactionExtent
 	return c.GRPC.Transfer(from, to, amount)
 }
 
+// GetTrc10Balance returns the amount of assetId held by addr, zero when the
+// account is not activated or holds none.
 func (c *Client) GetTrc10Balance(addr, assetId string) (int64, error) {
 	err := c.keepConnect()
 	if err != nil {
 		return 0, err
 	}
-	acc, err := c.GRPC.GetAccount(addr)
+	acc, err := c.GetAccount(addr)
 	if err != nil || acc == nil {
 		return 0, err
 	}
-	for key, value := range acc.AssetV2 {
-		if key == assetId {
-			return value, nil
+	return acc.AssetV2[assetId], nil
+}
+
+// GetAssetIssueByID returns the TRC-10 asset assetId, nil when no such asset
+// was issued.
+func (c *Client) GetAssetIssueByID(assetId string) (*core.AssetIssueContract, error) {
+	err := c.keepConnect()
+	if err != nil {
+		return nil, err
+	}
+	asset, err := c.GRPC.GetAssetIssueByID(assetId)
+	if err != nil {
+		return nil, err
+	}
+	if asset.GetId() == "" {
+		return nil, nil
+	}
+	return asset, nil
+}
+
+// GetAccount returns the on-chain account of addr, nil when the address was
+// never activated.
+func (c *Client) GetAccount(addr string) (*core.Account, error) {
+	err := c.keepConnect()
+	if err != nil {
+		return nil, err
+	}
+	acc, err := c.GRPC.GetAccount(addr)
+	if err != nil {
+		if err.Error() == "account not found" {
+			return nil, nil
 		}
+		return nil, err
+	}
+	return acc, nil
+}
+
+func (c *Client) GetAccountResource(addr string) (*api.AccountResourceMessage, error) {
+	err := c.keepConnect()
+	if err != nil {
+		return nil, err
 	}
-	return 0, fmt.Errorf("%s do not find this assetID=%s amount", addr, assetId)
+	return c.GRPC.GetAccountResource(addr)
+}
+
+// GetChainParameters returns the network parameters keyed by name, e.g.
+// getTransactionFee or getEnergyFee.
+func (c *Client) GetChainParameters() (map[string]int64, error) {
+	err := c.keepConnect()
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*60)
+	defer cancel()
+	result, err := c.GRPC.Client.GetChainParameters(ctx, new(api.EmptyMessage))
+	if err != nil {
+		return nil, err
+	}
+	params := make(map[string]int64, len(result.ChainParameter))
+	for _, param := range result.ChainParameter {
+		params[param.Key] = param.Value
+	}
+	return params, nil
 }
 
 func (c *Client) GetTrxBalance(addr string) (*account.Account, error) {
@@ -100,6 +166,51 @@ func (c *Client) GetTrc20Balance(addr, contractAddress string) (*big.Int, error)
 	return c.GRPC.TRC20ContractBalance(addr, contractAddress)
 }
 
+func (c *Client) GetTrc20Decimals(contractAddress string) (int, error) {
+	err := c.keepConnect()
+	if err != nil {
+		return 0, err
+	}
+	decimals, err := c.GRPC.TRC20GetDecimals(contractAddress)
+	if err != nil {
+		return 0, err
+	}
+	if !decimals.IsInt64() || decimals.Int64() > 77 {
+		return 0, fmt.Errorf("contract %s returned invalid decimals %s", contractAddress, decimals)
+	}
+	return int(decimals.Int64()), nil
+}
+
+// EstimateTrc20TransferEnergy dry runs a TRC-20 transfer and returns the
+// energy it consumes. Nodes without EstimateEnergy enabled are asked through
+// TriggerConstantContract instead.
+func (c *Client) EstimateTrc20TransferEnergy(from, to, contract string, amount *big.Int) (int64, error) {
+	err := c.keepConnect()
+	if err != nil {
+		return 0, err
+	}
+	params := fmt.Sprintf(`[{"address":"%s"},{"uint256":"%s"}]`, to, amount.String())
+
+	estimate, err := c.GRPC.EstimateEnergy(from, contract, trc20TransferMethod, params, 0, "", 0)
+	if err == nil && estimate.EnergyRequired > 0 {
+		return estimate.EnergyRequired, nil
+	}
+
+	tx, err := c.GRPC.TriggerConstantContract(from, contract, trc20TransferMethod, params)
+	if err != nil {
+		return 0, err
+	}
+	if !tx.GetResult().GetResult() {
+		return 0, errors.New("transfer would fail: " + string(tx.GetResult().GetMessage()))
+	}
+	for _, ret := range tx.GetTransaction().GetRet() {
+		if ret.ContractRet != core.Transaction_Result_SUCCESS && ret.ContractRet != core.Transaction_Result_DEFAULT {
+			return 0, errors.New("transfer would fail: " + ret.ContractRet.String())
+		}
+	}
+	return tx.EnergyUsed, nil
+}
+
 func (c *Client) TransferTrc10(from, to, assetId string, amount int64) (*api.TransactionExtention, error) {
 	err := c.keepConnect()
 	if err != nil {
@@ -125,12 +236,89 @@ func (c *Client) TransferTrc20(from, to, contract string, amount *big.Int, feeLi
 	return c.GRPC.TRC20Send(from, to, contract, amount, feeLimit)
 }
 
+func (c *Client) FreezeBalanceV2(from string, resource core.ResourceCode, amount int64) (*api.TransactionExtention, error) {
+	err := c.keepConnect()
+	if err != nil {
+		return nil, err
+	}
+	return c.GRPC.FreezeBalanceV2(from, resource, amount)
+}
+
+func (c *Client) UnfreezeBalanceV2(from string, resource core.ResourceCode, amount int64) (*api.TransactionExtention, error) {
+	err := c.keepConnect()
+	if err != nil {
+		return nil, err
+	}
+	return c.GRPC.UnfreezeBalanceV2(from, resource, amount)
+}
+
+// WithdrawExpireUnfreeze withdraws every unstaked amount whose waiting
+// period is over.
+func (c *Client) WithdrawExpireUnfreeze(from string) (*api.TransactionExtention, error) {
+	err := c.keepConnect()
+	if err != nil {
+		return nil, err
+	}
+	return checkTransaction(c.GRPC.WithdrawExpireUnfreeze(from, time.Now().UnixMilli()))
+}
+
+// DelegateResource delegates the resource of amount staked sun to to, a
+// positive lockPeriod in blocks keeps it from being undelegated until then.
+func (c *Client) DelegateResource(from, to string, resource core.ResourceCode, amount, lockPeriod int64) (*api.TransactionExtention, error) {
+	err := c.keepConnect()
+	if err != nil {
+		return nil, err
+	}
+	return checkTransaction(c.GRPC.DelegateResource(from, to, resource, amount, lockPeriod > 0, lockPeriod))
+}
+
+func (c *Client) UnDelegateResource(owner, receiver string, resource core.ResourceCode, amount int64) (*api.TransactionExtention, error) {
+	err := c.keepConnect()
+	if err != nil {
+		return nil, err
+	}
+	return checkTransaction(c.GRPC.UnDelegateResource(owner, receiver, resource, amount, false))
+}
+
+func (c *Client) GetTransactionSignWeight(tx *core.Transaction) (*api.TransactionSignWeight, error) {
+	err := c.keepConnect()
+	if err != nil {
+		return nil, err
+	}
+	return c.GRPC.GetTransactionSignWeight(tx)
+}
+
+// UpdateAccountPermission replaces the owner and active permissions of from,
+// see client.GrpcClient.UpdateAccountPermission for the map layout.
+func (c *Client) UpdateAccountPermission(from string, owner map[string]interface{}, actives []map[string]interface{}) (*api.TransactionExtention, error) {
+	err := c.keepConnect()
+	if err != nil {
+		return nil, err
+	}
+	return c.GRPC.UpdateAccountPermission(from, owner, nil, actives)
+}
+
+// checkTransaction turns a transaction the node refused to build into an
+// error, not every SDK call checks the result code itself.
+func checkTransaction(tx *api.TransactionExtention, err error) (*api.TransactionExtention, error) {
+	if err != nil {
+		return nil, err
+	}
+	if tx.GetResult().GetCode() != api.Return_SUCCESS || tx.GetTransaction() == nil {
+		return nil, errors.New("bad transaction: " + string(tx.GetResult().GetMessage()))
+	}
+	return tx, nil
+}
+
 func (c *Client) BroadcastTransaction(transaction *core.Transaction) error {
 	err := c.keepConnect()
 	if err != nil {
 		return err
 	}
 	result, err := c.GRPC.Broadcast(transaction)
+	if result.GetCode() == api.Return_DUP_TRANSACTION_ERROR {
+		return ErrDuplicateTransaction
+	}
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,193 @@
+package tron
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"slices"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/fbsobreira/gotron-sdk/pkg/address"
+	"github.com/fbsobreira/gotron-sdk/pkg/proto/api"
+	"github.com/fbsobreira/gotron-sdk/pkg/proto/core"
+	grpcs "wallet/pkg/hdwallet/tron/grpc"
+	"wallet/pkg/hdwallet/tron/sign"
+	"wallet/pkg/signer"
+)
+
+// Tron allows a transaction to wait at most 24 hours for its signatures.
+const MaxMultisigExpiration = 24 * time.Hour
+
+var (
+	ErrNotPermitted           = errors.New("key is not part of the permission")
+	ErrAlreadySigned          = errors.New("key already signed the transaction")
+	ErrInvalidSignatureFormat = errors.New("invalid signature format")
+	ErrNoOperations           = errors.New("active permission needs at least one operation")
+)
+
+// multisigNode is the part of the node the multisig flow talks to, tests
+// replace it with a fake.
+type multisigNode interface {
+	GetTransactionSignWeight(tx *core.Transaction) (*api.TransactionSignWeight, error)
+	UpdateAccountPermission(from string, owner map[string]interface{}, actives []map[string]interface{}) (*api.TransactionExtention, error)
+	BroadcastTransaction(tx *core.Transaction) error
+}
+
+// multisigClient is Client, set by Init.
+var multisigClient multisigNode
+
+// SignWeight is how far a transaction got towards the threshold of the
+// permission it is sent under.
+type SignWeight struct {
+	PermissionID   int32    `json:"permission_id"`
+	PermissionName string   `json:"permission_name"`
+	Threshold      int64    `json:"threshold"`
+	CurrentWeight  int64    `json:"current_weight"`
+	Approved       []string `json:"approved"`
+	Enough         bool     `json:"enough"`
+}
+
+// BuildTrxTransfer builds an unsigned TRX transfer from ownerAddress.
+func BuildTrxTransfer(ownerAddress, receiverAddress string, amount int64) (*core.Transaction, error) {
+	tx, err := Client.Transfer(ownerAddress, receiverAddress, amount)
+	if err != nil {
+		return nil, err
+	}
+	return tx.Transaction, nil
+}
+
+// BuildTrc20Transfer builds an unsigned TRC-20 transfer from ownerAddress.
+func BuildTrc20Transfer(ownerAddress string, token *TokenInfo, receiverAddress string, amount *big.Int, feeLimit int64) (*core.Transaction, error) {
+	tx, err := Client.TransferTrc20(ownerAddress, receiverAddress, token.Contract, amount, feeLimit)
+	if err != nil {
+		return nil, err
+	}
+	return tx.Transaction, nil
+}
+
+// Permission is one owner or active permission of an account, keys map
+// base58 addresses to their weight. Operations names the contract types an
+// active permission may sign, e.g. TransferContract.
+type Permission struct {
+	Name       string
+	Threshold  int64
+	Keys       map[string]int64
+	Operations []string
+}
+
+// BuildUpdatePermission builds an unsigned transaction replacing the owner
+// and active permissions of ownerAddress. An active permission without
+// operations could sign nothing, it is rejected with ErrNoOperations.
+func BuildUpdatePermission(ownerAddress string, owner Permission, actives []Permission) (*core.Transaction, error) {
+	ownerMap := map[string]interface{}{"threshold": owner.Threshold, "keys": owner.Keys}
+	activeMaps := make([]map[string]interface{}, 0, len(actives))
+	for _, active := range actives {
+		if len(active.Operations) == 0 {
+			return nil, ErrNoOperations
+		}
+		operations := make(map[string]bool, len(active.Operations))
+		for _, operation := range active.Operations {
+			operations[operation] = true
+		}
+		activeMaps = append(activeMaps, map[string]interface{}{
+			"name":       active.Name,
+			"threshold":  active.Threshold,
+			"operations": operations,
+			"keys":       active.Keys,
+		})
+	}
+	tx, err := multisigClient.UpdateAccountPermission(ownerAddress, ownerMap, activeMaps)
+	if err != nil {
+		return nil, err
+	}
+	return tx.Transaction, nil
+}
+
+// PrepareMultisig sends tx under permissionID and gives the holders until
+// expiration to sign it. It returns the transaction id, which changes with
+// the raw data.
+func PrepareMultisig(tx *core.Transaction, permissionID int32, expiration time.Time) ([]byte, error) {
+	for _, contract := range tx.GetRawData().GetContract() {
+		contract.PermissionId = permissionID
+	}
+	tx.RawData.Expiration = expiration.UnixMilli()
+	return sign.GetTransactionHash(tx)
+}
+
+// Sign adds the signature of holder to tx, whose signatures carry weight so
+// far.
+func Sign(ctx context.Context, tx *core.Transaction, weight *SignWeight, holder signer.Signer) error {
+	if slices.Contains(weight.Approved, SignerAddress(holder)) {
+		return ErrAlreadySigned
+	}
+	_, err := sign.TransactionSign(ctx, tx, holder)
+	return err
+}
+
+// AddSignature appends a 65 byte signature over the transaction id made
+// outside the wallet, e.g. on a hardware device. Ethereum style signatures
+// carry V as 27 or 28, they are stored as 0 or 1.
+func AddSignature(tx *core.Transaction, weight *SignWeight, signature []byte) error {
+	if len(signature) != 65 {
+		return ErrInvalidSignatureFormat
+	}
+	signature = slices.Clone(signature)
+	if signature[64] >= 27 {
+		signature[64] -= 27
+	}
+	hash, err := sign.GetTransactionHash(tx)
+	if err != nil {
+		return err
+	}
+	publicKey, err := crypto.SigToPub(hash, signature)
+	if err != nil {
+		return ErrInvalidSignatureFormat
+	}
+	if slices.Contains(weight.Approved, address.PubkeyToAddress(*publicKey).String()) {
+		return ErrAlreadySigned
+	}
+	tx.Signature = append(tx.Signature, signature)
+	return nil
+}
+
+// GetSignWeight asks the node how much weight the signatures of tx carry.
+// Signatures by keys outside the permission are reported as ErrNotPermitted.
+func GetSignWeight(tx *core.Transaction) (*SignWeight, error) {
+	weight, err := multisigClient.GetTransactionSignWeight(tx)
+	if err != nil {
+		return nil, err
+	}
+	switch weight.GetResult().GetCode() {
+	case api.TransactionSignWeight_Result_ENOUGH_PERMISSION, api.TransactionSignWeight_Result_NOT_ENOUGH_PERMISSION:
+	case api.TransactionSignWeight_Result_PERMISSION_ERROR:
+		return nil, ErrNotPermitted
+	case api.TransactionSignWeight_Result_SIGNATURE_FORMAT_ERROR, api.TransactionSignWeight_Result_COMPUTE_ADDRESS_ERROR:
+		return nil, ErrInvalidSignatureFormat
+	default:
+		return nil, errors.New("sign weight: " + weight.GetResult().GetMessage())
+	}
+
+	result := &SignWeight{
+		PermissionID:   weight.GetPermission().GetId(),
+		PermissionName: weight.GetPermission().GetPermissionName(),
+		Threshold:      weight.GetPermission().GetThreshold(),
+		CurrentWeight:  weight.CurrentWeight,
+		Enough:         weight.GetResult().GetCode() == api.TransactionSignWeight_Result_ENOUGH_PERMISSION,
+	}
+	for _, approved := range weight.ApprovedList {
+		result.Approved = append(result.Approved, address.Address(approved).String())
+	}
+	return result, nil
+}
+
+// Broadcast sends a transaction whose signatures reach the threshold. One
+// the node already holds was sent by a concurrent signer and counts as
+// broadcast.
+func Broadcast(tx *core.Transaction) error {
+	err := multisigClient.BroadcastTransaction(tx)
+	if errors.Is(err, grpcs.ErrDuplicateTransaction) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,232 @@
+package tron
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/fbsobreira/gotron-sdk/pkg/address"
+	"github.com/fbsobreira/gotron-sdk/pkg/proto/api"
+	"github.com/fbsobreira/gotron-sdk/pkg/proto/core"
+	grpcs "wallet/pkg/hdwallet/tron/grpc"
+	"wallet/pkg/hdwallet/tron/sign"
+	"wallet/pkg/signer"
+)
+
+// fakeNode weighs signatures against one permission the way a node does
+// and records what it is asked to build or broadcast.
+type fakeNode struct {
+	keys       map[string]int64
+	threshold  int64
+	broadcast  error
+	sent       int
+	owner      map[string]interface{}
+	actives    []map[string]interface{}
+	updateFrom string
+}
+
+func (f *fakeNode) GetTransactionSignWeight(tx *core.Transaction) (*api.TransactionSignWeight, error) {
+	weight := &api.TransactionSignWeight{
+		Permission: &core.Permission{Id: 2, PermissionName: "ops", Threshold: f.threshold},
+		Result:     &api.TransactionSignWeight_Result{},
+	}
+	hash, err := sign.GetTransactionHash(tx)
+	if err != nil {
+		return nil, err
+	}
+	for _, signature := range tx.Signature {
+		publicKey, err := crypto.SigToPub(hash, signature)
+		if err != nil {
+			weight.Result.Code = api.TransactionSignWeight_Result_SIGNATURE_FORMAT_ERROR
+			return weight, nil
+		}
+		holder := address.PubkeyToAddress(*publicKey)
+		keyWeight, ok := f.keys[holder.String()]
+		if !ok {
+			weight.Result.Code = api.TransactionSignWeight_Result_PERMISSION_ERROR
+			return weight, nil
+		}
+		weight.ApprovedList = append(weight.ApprovedList, holder)
+		weight.CurrentWeight += keyWeight
+	}
+	weight.Result.Code = api.TransactionSignWeight_Result_NOT_ENOUGH_PERMISSION
+	if weight.CurrentWeight >= f.threshold {
+		weight.Result.Code = api.TransactionSignWeight_Result_ENOUGH_PERMISSION
+	}
+	return weight, nil
+}
+
+func (f *fakeNode) UpdateAccountPermission(from string, owner map[string]interface{}, actives []map[string]interface{}) (*api.TransactionExtention, error) {
+	f.updateFrom, f.owner, f.actives = from, owner, actives
+	return &api.TransactionExtention{Transaction: &core.Transaction{RawData: &core.TransactionRaw{}}}, nil
+}
+
+func (f *fakeNode) BroadcastTransaction(*core.Transaction) error {
+	f.sent++
+	return f.broadcast
+}
+
+func useFakeNode(t *testing.T, node *fakeNode) {
+	previous := multisigClient
+	multisigClient = node
+	t.Cleanup(func() { multisigClient = previous })
+}
+
+func newMultisigTx(t *testing.T) (*core.Transaction, []byte) {
+	tx := &core.Transaction{RawData: &core.TransactionRaw{
+		Contract: []*core.Transaction_Contract{{Type: core.Transaction_Contract_TransferContract}},
+	}}
+	txID, err := PrepareMultisig(tx, 2, time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tx, txID
+}
+
+func generateKey(t *testing.T) *ecdsa.PrivateKey {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func TestPrepareMultisig(t *testing.T) {
+	tx := &core.Transaction{RawData: &core.TransactionRaw{
+		Contract:   []*core.Transaction_Contract{{Type: core.Transaction_Contract_TransferContract}},
+		Expiration: time.Now().Add(time.Minute).UnixMilli(),
+	}}
+	before, err := sign.GetTransactionHash(tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expiration := time.Now().Add(time.Hour)
+	txID, err := PrepareMultisig(tx, 2, expiration)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(txID, before) {
+		t.Error("transaction id must follow the raw data")
+	}
+	if tx.RawData.Contract[0].PermissionId != 2 || tx.RawData.Expiration != expiration.UnixMilli() {
+		t.Errorf("raw data %+v", tx.RawData)
+	}
+
+	if err = AddSignature(tx, &SignWeight{}, make([]byte, 64)); err != ErrInvalidSignatureFormat {
+		t.Errorf("expected ErrInvalidSignatureFormat, got %v", err)
+	}
+	if err = AddSignature(tx, &SignWeight{}, make([]byte, 65)); err != ErrInvalidSignatureFormat {
+		t.Errorf("unrecoverable signature: expected ErrInvalidSignatureFormat, got %v", err)
+	}
+}
+
+func TestMultisigThreshold(t *testing.T) {
+	ctx := context.Background()
+	walletKey, deviceKey := generateKey(t), generateKey(t)
+	node := &fakeNode{
+		keys: map[string]int64{
+			address.PubkeyToAddress(walletKey.PublicKey).String(): 1,
+			address.PubkeyToAddress(deviceKey.PublicKey).String(): 2,
+		},
+		threshold: 3,
+	}
+	useFakeNode(t, node)
+	tx, txID := newMultisigTx(t)
+	holder := signer.NewLocalSigner(walletKey)
+
+	weight, err := GetSignWeight(tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = Sign(ctx, tx, weight, holder); err != nil {
+		t.Fatal(err)
+	}
+	if weight, err = GetSignWeight(tx); err != nil {
+		t.Fatal(err)
+	}
+	if weight.Enough || weight.CurrentWeight != 1 || weight.Threshold != 3 || len(weight.Approved) != 1 {
+		t.Errorf("after one signature %+v", weight)
+	}
+	if err = Sign(ctx, tx, weight, holder); err != ErrAlreadySigned {
+		t.Errorf("expected ErrAlreadySigned, got %v", err)
+	}
+
+	// The device signs the transaction id Ethereum style, V is 27 or 28.
+	signature, err := crypto.Sign(txID, deviceKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ethSignature := append([]byte{}, signature...)
+	ethSignature[64] += 27
+	if err = AddSignature(tx, weight, ethSignature); err != nil {
+		t.Fatal(err)
+	}
+	if tx.Signature[1][64] != signature[64] {
+		t.Errorf("V stored as %d, want %d", tx.Signature[1][64], signature[64])
+	}
+	if weight, err = GetSignWeight(tx); err != nil {
+		t.Fatal(err)
+	}
+	if !weight.Enough || weight.CurrentWeight != 3 {
+		t.Errorf("after both signatures %+v", weight)
+	}
+	if err = AddSignature(tx, weight, signature); err != ErrAlreadySigned {
+		t.Errorf("same key with V 0/1: expected ErrAlreadySigned, got %v", err)
+	}
+
+	// A concurrent signer broadcast first, the node knows the transaction.
+	node.broadcast = grpcs.ErrDuplicateTransaction
+	if err = Broadcast(tx); err != nil || node.sent != 1 {
+		t.Errorf("duplicate broadcast: %v", err)
+	}
+}
+
+func TestMultisigNotPermitted(t *testing.T) {
+	node := &fakeNode{keys: map[string]int64{}, threshold: 1}
+	useFakeNode(t, node)
+	tx, txID := newMultisigTx(t)
+
+	signature, err := crypto.Sign(txID, generateKey(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = AddSignature(tx, &SignWeight{}, signature); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = GetSignWeight(tx); err != ErrNotPermitted {
+		t.Errorf("expected ErrNotPermitted, got %v", err)
+	}
+}
+
+func TestBuildUpdatePermission(t *testing.T) {
+	node := &fakeNode{}
+	useFakeNode(t, node)
+	const account = "TXLAQ63Xg1NAzckPwKHvzw7CSEmLMEqcdj"
+	keys := map[string]int64{account: 1}
+	owner := Permission{Threshold: 1, Keys: keys}
+
+	active := Permission{Name: "ops", Threshold: 1, Keys: keys, Operations: []string{"TransferContract", "TriggerSmartContract"}}
+	if _, err := BuildUpdatePermission(account, owner, []Permission{active}); err != nil {
+		t.Fatal(err)
+	}
+	if node.updateFrom != account || node.owner["threshold"] != int64(1) || len(node.actives) != 1 {
+		t.Fatalf("update of %s owner %v actives %v", node.updateFrom, node.owner, node.actives)
+	}
+	operations, ok := node.actives[0]["operations"].(map[string]bool)
+	if !ok || len(operations) != 2 || !operations["TransferContract"] || !operations["TriggerSmartContract"] {
+		t.Errorf("operations %v", node.actives[0]["operations"])
+	}
+
+	node.actives = nil
+	active.Operations = nil
+	if _, err := BuildUpdatePermission(account, owner, []Permission{active}); err != ErrNoOperations {
+		t.Errorf("expected ErrNoOperations, got %v", err)
+	}
+	if node.actives != nil {
+		t.Error("an active permission without operations reached the node")
+	}
+}
@@ -1,26 +1,25 @@
 package sign
 
 import (
-	"crypto/ecdsa"
+	"context"
 	"crypto/sha256"
 
-	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/fbsobreira/gotron-sdk/pkg/proto/core"
 	"google.golang.org/protobuf/proto"
 )
 
-func TransactionSign(transaction *core.Transaction, fromPrivateKey []byte) (*core.Transaction, error) {
-	privateKey := crypto.ToECDSAUnsafe(fromPrivateKey)
+// HashSigner produces a 65 byte [R || S || V] secp256k1 signature of a
+// 32 byte hash, see signer.Signer for the implementations.
+type HashSigner interface {
+	SignHash(ctx context.Context, hash []byte) ([]byte, error)
+}
 
-	defer zeroKey(privateKey)
-	rawData, err := proto.Marshal(transaction.GetRawData())
+func TransactionSign(ctx context.Context, transaction *core.Transaction, signer HashSigner) (*core.Transaction, error) {
+	hash, err := GetTransactionHash(transaction)
 	if err != nil {
 		return nil, err
 	}
-	h256h := sha256.New()
-	h256h.Write(rawData)
-	hash := h256h.Sum(nil)
-	signature, err := crypto.Sign(hash, privateKey)
+	signature, err := signer.SignHash(ctx, hash)
 	if err != nil {
 		return nil, err
 	}
@@ -39,11 +38,3 @@ func GetTransactionHash(transaction *core.Transaction) ([]byte, error) {
 	hash := h256h.Sum(nil)
 	return hash, nil
 }
-
-// zeroKey zeroes a private key in memory.
-func zeroKey(k *ecdsa.PrivateKey) {
-	b := k.D.Bits()
-	for i := range b {
-		b[i] = 0
-	}
-}
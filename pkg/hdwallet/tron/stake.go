@@ -0,0 +1,90 @@
+package tron
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"strings"
+
+	"github.com/fbsobreira/gotron-sdk/pkg/proto/api"
+	"github.com/fbsobreira/gotron-sdk/pkg/proto/core"
+	"wallet/pkg/hdwallet/tron/sign"
+	"wallet/pkg/signer"
+)
+
+// Stake 2.0 operations. Amounts are sun of staked TRX, unstaked TRX can be
+// withdrawn after the network's waiting period with WithdrawExpireUnfreeze.
+
+const (
+	ResourceBandwidth = "bandwidth"
+	ResourceEnergy    = "energy"
+)
+
+var ErrInvalidResource = errors.New("resource must be bandwidth or energy")
+
+func ParseResource(resource string) (core.ResourceCode, error) {
+	switch strings.ToLower(resource) {
+	case ResourceBandwidth:
+		return core.ResourceCode_BANDWIDTH, nil
+	case ResourceEnergy:
+		return core.ResourceCode_ENERGY, nil
+	default:
+		return 0, ErrInvalidResource
+	}
+}
+
+func FreezeBalance(ctx context.Context, sender signer.Signer, resource core.ResourceCode, amount int64) (txid string, err error) {
+	tx, err := Client.FreezeBalanceV2(SignerAddress(sender), resource, amount)
+	if err != nil {
+		return "", err
+	}
+	return signAndBroadcast(ctx, sender, tx)
+}
+
+func UnfreezeBalance(ctx context.Context, sender signer.Signer, resource core.ResourceCode, amount int64) (txid string, err error) {
+	tx, err := Client.UnfreezeBalanceV2(SignerAddress(sender), resource, amount)
+	if err != nil {
+		return "", err
+	}
+	return signAndBroadcast(ctx, sender, tx)
+}
+
+func WithdrawExpireUnfreeze(ctx context.Context, sender signer.Signer) (txid string, err error) {
+	tx, err := Client.WithdrawExpireUnfreeze(SignerAddress(sender))
+	if err != nil {
+		return "", err
+	}
+	return signAndBroadcast(ctx, sender, tx)
+}
+
+func DelegateResource(ctx context.Context, sender signer.Signer, receiverAddress string, resource core.ResourceCode, amount, lockPeriod int64) (txid string, err error) {
+	senderAddress := SignerAddress(sender)
+	if senderAddress == receiverAddress {
+		return "", ErrSelfTransfer
+	}
+	tx, err := Client.DelegateResource(senderAddress, receiverAddress, resource, amount, lockPeriod)
+	if err != nil {
+		return "", err
+	}
+	return signAndBroadcast(ctx, sender, tx)
+}
+
+func UnDelegateResource(ctx context.Context, sender signer.Signer, receiverAddress string, resource core.ResourceCode, amount int64) (txid string, err error) {
+	tx, err := Client.UnDelegateResource(SignerAddress(sender), receiverAddress, resource, amount)
+	if err != nil {
+		return "", err
+	}
+	return signAndBroadcast(ctx, sender, tx)
+}
+
+func signAndBroadcast(ctx context.Context, sender signer.Signer, tx *api.TransactionExtention) (string, error) {
+	signTx, err := sign.TransactionSign(ctx, tx.Transaction, sender)
+	if err != nil {
+		return "", err
+	}
+	err = Client.BroadcastTransaction(signTx)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(tx.Txid), nil
+}
@@ -0,0 +1,47 @@
+package tron
+
+import (
+	"wallet/pkg/common/config"
+	"wallet/pkg/hdwallet/registry"
+)
+
+const USDTSymbol = "USDT"
+
+var ErrUnknownToken = registry.ErrUnknownToken
+
+// TokenInfo is a TRC-20 token of the registry keyed by base58 contract,
+// Decimals is read from the contract the first time the token is used.
+type TokenInfo = registry.Token
+
+// tokenRegistry is the shared registry and the node call that reads the
+// decimals of a contract.
+type tokenRegistry struct {
+	*registry.Tokens
+	decimals func(contract string) (int, error)
+}
+
+// tokens is the registry built by Init from the configuration.
+var tokens *tokenRegistry
+
+// newTokenRegistry registers list, plus the legacy USDT contract under USDT
+// unless the list already names that symbol.
+func newTokenRegistry(list []config.TronTokenConfig, usdtContract string, decimals func(contract string) (int, error)) *tokenRegistry {
+	r := &tokenRegistry{Tokens: registry.NewTokens(nil), decimals: decimals}
+	for _, token := range list {
+		r.Add(token.Symbol, token.Contract)
+	}
+	if !r.Has(USDTSymbol) && usdtContract != "" {
+		r.Add(USDTSymbol, usdtContract)
+	}
+	return r
+}
+
+// Token resolves a registered token by symbol or contract address.
+func (r *tokenRegistry) Token(key string) (*TokenInfo, error) {
+	return r.Resolve(key, r.decimals)
+}
+
+// Token resolves a registered TRC-20 token by symbol or contract address.
+func Token(key string) (*TokenInfo, error) {
+	return tokens.Token(key)
+}
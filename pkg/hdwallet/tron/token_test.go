@@ -0,0 +1,59 @@
+package tron
+
+import (
+	"testing"
+
+	"wallet/pkg/common/config"
+)
+
+func TestTokenRegistry(t *testing.T) {
+	const (
+		usdc = "TEkxiTehnzSmSe2XqrBj4w32RUN966rdz8"
+		usdt = "TXLAQ63Xg1NAzckPwKHvzw7CSEmLMEqcdj"
+	)
+	r := newTokenRegistry([]config.TronTokenConfig{{Symbol: "usdc", Contract: usdc}}, usdt, func(string) (int, error) {
+		return 6, nil
+	})
+
+	token, err := r.Token("USDC")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token.Symbol != "USDC" || token.Contract != usdc || token.Decimals != 6 {
+		t.Errorf("token %+v", token)
+	}
+	byContract, err := r.Token(usdc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if byContract.Symbol != "USDC" {
+		t.Errorf("token by contract %+v", byContract)
+	}
+
+	// The legacy contract is registered as USDT.
+	token, err = r.Token("usdt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token.Contract != usdt || token.Decimals != 6 {
+		t.Errorf("usdt %+v", token)
+	}
+
+	if _, err = r.Token("TRX"); err != ErrUnknownToken {
+		t.Errorf("expected ErrUnknownToken, got %v", err)
+	}
+}
+
+func TestTokenRegistryListedUSDT(t *testing.T) {
+	const listed = "TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t"
+	r := newTokenRegistry([]config.TronTokenConfig{{Symbol: "USDT", Contract: listed}}, "TXLAQ63Xg1NAzckPwKHvzw7CSEmLMEqcdj", func(string) (int, error) {
+		return 6, nil
+	})
+	token, err := r.Token(USDTSymbol)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token.Contract != listed {
+		t.Errorf("listed USDT replaced by the legacy contract: %+v", token)
+	}
+}
@@ -1,12 +1,15 @@
 package tron
 
 import (
+	"context"
 	"encoding/hex"
 	"github.com/fbsobreira/gotron-sdk/pkg/account"
+	"github.com/fbsobreira/gotron-sdk/pkg/address"
+	"github.com/fbsobreira/gotron-sdk/pkg/proto/core"
 	"math/big"
 	"wallet/pkg/common/config"
 	grpcs "wallet/pkg/hdwallet/tron/grpc"
-	"wallet/pkg/hdwallet/tron/sign"
+	"wallet/pkg/signer"
 	"wallet/pkg/zlogger"
 )
 
@@ -18,15 +21,23 @@ func Init() {
 	if err != nil {
 		zlogger.Errorf("Failed to initialize TRON gRPC client: %v", err)
 	}
+	multisigClient = Client
+	tokens = newTokenRegistry(config.Config.Blockchain.TronTokens, config.Config.Blockchain.TronUSDTContract, func(contract string) (int, error) {
+		return Client.GetTrc20Decimals(contract)
+	})
 	zlogger.Info("Initialized TRON gRPC client successfully")
 }
 
-func TransferTrc20(senderAddress string, receiverAddress string, fromPrivateKey []byte, amountTransfer *big.Int) (txid string, err error) {
-	tx, err := Client.TransferTrc20(senderAddress, receiverAddress, config.Config.Blockchain.TronUSDTContract, amountTransfer, 50000000)
+// TransferTrc20 sends amountTransfer, in the smallest unit of token, to
+// receiverAddress. feeLimit caps the energy cost in sun, see
+// EstimateTrc20TransferFee.
+func TransferTrc20(ctx context.Context, sender signer.Signer, token *TokenInfo, receiverAddress string, amountTransfer *big.Int, feeLimit int64) (txid string, err error) {
+	senderAddress := SignerAddress(sender)
+	tx, err := Client.TransferTrc20(senderAddress, receiverAddress, token.Contract, amountTransfer, feeLimit)
 	if err != nil {
 		return "", err
 	}
-	signTx, err := sign.TransactionSign(tx.Transaction, fromPrivateKey)
+	signTx, err := sender.SignTronTransaction(ctx, tx.Transaction)
 	if err != nil {
 		return "", err
 	}
@@ -42,12 +53,18 @@ func GetTrc20Balance(address string, contract string) (*big.Int, error) {
 	return Client.GetTrc20Balance(address, contract)
 }
 
-func TransferTrc10(senderAddress string, receiverAddress string, fromPrivateKey []byte, amountTransfer int64) (txId string, err error) {
-	tx, err := Client.TransferTrc10(senderAddress, receiverAddress, "trx", amountTransfer)
+// TransferTrc10 sends amountTransfer, in the smallest unit of the asset, of
+// the TRC-10 asset assetID to receiverAddress.
+func TransferTrc10(ctx context.Context, sender signer.Signer, assetID, receiverAddress string, amountTransfer int64) (txId string, err error) {
+	senderAddress := SignerAddress(sender)
+	if senderAddress == receiverAddress {
+		return "", ErrSelfTransfer
+	}
+	tx, err := Client.TransferTrc10(senderAddress, receiverAddress, assetID, amountTransfer)
 	if err != nil {
 		return "", err
 	}
-	signTx, err := sign.TransactionSign(tx.Transaction, fromPrivateKey)
+	signTx, err := sender.SignTronTransaction(ctx, tx.Transaction)
 	if err != nil {
 		return "", err
 	}
@@ -59,12 +76,16 @@ func TransferTrc10(senderAddress string, receiverAddress string, fromPrivateKey
 	return hex.EncodeToString(tx.Txid), nil
 }
 
-func TransferTrx(senderAddress string, receiverAddress string, fromPrivateKey []byte, amountTransfer int64) (txid string, err error) {
+func TransferTrx(ctx context.Context, sender signer.Signer, receiverAddress string, amountTransfer int64) (txid string, err error) {
+	senderAddress := SignerAddress(sender)
+	if senderAddress == receiverAddress {
+		return "", ErrSelfTransfer
+	}
 	tx, err := Client.Transfer(senderAddress, receiverAddress, amountTransfer)
 	if err != nil {
 		return "", err
 	}
-	signTx, err := sign.TransactionSign(tx.Transaction, fromPrivateKey)
+	signTx, err := sender.SignTronTransaction(ctx, tx.Transaction)
 	if err != nil {
 		return "", err
 	}
@@ -75,6 +96,19 @@ func TransferTrx(senderAddress string, receiverAddress string, fromPrivateKey []
 	return hex.EncodeToString(tx.Txid), nil
 }
 
+func GetTrc10Balance(address, assetID string) (int64, error) {
+	return Client.GetTrc10Balance(address, assetID)
+}
+
+func GetAccount(address string) (*core.Account, error) {
+	return Client.GetAccount(address)
+}
+
 func GetTrxBalance(address string) (*account.Account, error) {
 	return Client.GetTrxBalance(address)
 }
+
+// SignerAddress returns the base58 Tron address of sender.
+func SignerAddress(sender signer.Signer) string {
+	return address.PubkeyToAddress(*sender.PublicKey()).String()
+}
@@ -0,0 +1,72 @@
+package hdwallet
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+
+	geth "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	tronSdk "github.com/fbsobreira/gotron-sdk/pkg/address"
+	"github.com/tyler-smith/go-bip32"
+)
+
+var ErrNotPublicKey = errors.New("extended key is not a public key")
+
+// DeriveAccountKey derives the account level key m/44'/coinType'/account'.
+func DeriveAccountKey(masterKey *bip32.Key, coinType, account uint32) (*bip32.Key, error) {
+	if account >= bip32.FirstHardenedChild {
+		return nil, ErrIndexOutOfRange
+	}
+	return NewDerivationPath(coinType, account, 0, 0)[:3].Derive(masterKey)
+}
+
+// ExportAccountXpub returns the serialized extended public key of
+// m/44'/coinType'/account', it can derive every receiving and change address
+// of the account without access to private key material.
+func ExportAccountXpub(masterKey *bip32.Key, coinType, account uint32) (string, error) {
+	accountKey, err := DeriveAccountKey(masterKey, coinType, account)
+	if err != nil {
+		return "", err
+	}
+	return accountKey.PublicKey().B58Serialize(), nil
+}
+
+// ParseXpub deserializes an extended public key, extended private keys are
+// rejected so watch-only callers never handle secrets by accident.
+func ParseXpub(xpub string) (*bip32.Key, error) {
+	key, err := bip32.B58Deserialize(xpub)
+	if err != nil {
+		return nil, err
+	}
+	if key.IsPrivate {
+		return nil, ErrNotPublicKey
+	}
+	return key, nil
+}
+
+// DerivePublicKeyFromXpub derives the non-hardened child change/index below
+// an account level extended public key.
+func DerivePublicKeyFromXpub(accountKey *bip32.Key, change, index uint32) (*ecdsa.PublicKey, error) {
+	if change >= bip32.FirstHardenedChild || index >= bip32.FirstHardenedChild {
+		return nil, ErrIndexOutOfRange
+	}
+	addressKey, err := DerivationPath{change, index}.Derive(accountKey)
+	if err != nil {
+		return nil, err
+	}
+
+	publicKey, err := crypto.DecompressPubkey(addressKey.Key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress public key: %v", err)
+	}
+	return publicKey, nil
+}
+
+func PublicKeyToEthAddress(publicKey *ecdsa.PublicKey) geth.Address {
+	return crypto.PubkeyToAddress(*publicKey)
+}
+
+func PublicKeyToTronAddress(publicKey *ecdsa.PublicKey) tronSdk.Address {
+	return tronSdk.PubkeyToAddress(*publicKey)
+}
@@ -0,0 +1,136 @@
+package kms
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"wallet/pkg/common/config"
+	"wallet/pkg/tools/secretbox"
+	"wallet/pkg/zlogger"
+)
+
+const (
+	ProviderFile   = "file"
+	ProviderEnv    = "env"
+	ProviderPkcs11 = "pkcs11"
+)
+
+var (
+	ErrNoProvider        = errors.New("kms provider is not configured")
+	ErrUnknownProvider   = errors.New("unknown kms provider")
+	ErrKeyMismatch       = errors.New("data key was wrapped by a different key-encryption key")
+	ErrInvalidWrappedKey = errors.New("wrapped data key is malformed")
+)
+
+// KeyProvider wraps and unwraps data keys with a key-encryption key (KEK)
+// that never leaves the provider.
+type KeyProvider interface {
+	// KeyID identifies the KEK, it is stored next to every wrapped data key
+	// so a rotation can tell which rows still need re-wrapping.
+	KeyID() string
+	WrapKey(dataKey []byte) ([]byte, error)
+	UnwrapKey(wrappedKey []byte) ([]byte, error)
+}
+
+// Envelope is a secret encrypted with a random data key, the data key itself
+// is stored wrapped by the KEK identified by KeyID.
+type Envelope struct {
+	KeyID      string
+	WrappedKey []byte
+	Ciphertext []byte
+}
+
+var KEK KeyProvider
+
+func Init() {
+	if config.Config.Kms.Provider == "" {
+		zlogger.Warnf("kms provider is not configured, stored wallets are unavailable")
+		return
+	}
+	provider, err := NewProvider(config.Config.Kms)
+	if err != nil {
+		zlogger.Errorf("init kms provider error %v", err)
+		panic(err)
+	}
+	KEK = provider
+	zlogger.Infof("Initialized kms provider %s", provider.KeyID())
+}
+
+func NewProvider(cfg config.KmsConfig) (KeyProvider, error) {
+	switch cfg.Provider {
+	case ProviderFile:
+		return NewFileProvider(cfg.KeyFile)
+	case ProviderEnv:
+		return NewEnvProvider(cfg.EnvVar)
+	case ProviderPkcs11:
+		return NewPkcs11Provider(cfg.Pkcs11.Module, cfg.Pkcs11.TokenLabel, cfg.Pkcs11.Pin, cfg.Pkcs11.KeyLabel)
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownProvider, cfg.Provider)
+	}
+}
+
+// Seal encrypts plaintext under a fresh data key wrapped by provider,
+// additionalData binds the ciphertext to its owner as in secretbox.Seal.
+func Seal(provider KeyProvider, plaintext, additionalData []byte) (*Envelope, error) {
+	if provider == nil {
+		return nil, ErrNoProvider
+	}
+
+	dataKey := make([]byte, secretbox.KeySize)
+	if _, err := io.ReadFull(rand.Reader, dataKey); err != nil {
+		return nil, err
+	}
+	defer secretbox.Zero(dataKey)
+
+	ciphertext, err := secretbox.Seal(dataKey, plaintext, additionalData)
+	if err != nil {
+		return nil, err
+	}
+	wrappedKey, err := provider.WrapKey(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	return &Envelope{KeyID: provider.KeyID(), WrappedKey: wrappedKey, Ciphertext: ciphertext}, nil
+}
+
+// Open decrypts an envelope produced by Seal.
+func Open(provider KeyProvider, envelope *Envelope, additionalData []byte) ([]byte, error) {
+	if provider == nil {
+		return nil, ErrNoProvider
+	}
+	if envelope.KeyID != provider.KeyID() {
+		return nil, fmt.Errorf("%w: %s", ErrKeyMismatch, envelope.KeyID)
+	}
+
+	dataKey, err := provider.UnwrapKey(envelope.WrappedKey)
+	if err != nil {
+		return nil, err
+	}
+	defer secretbox.Zero(dataKey)
+
+	return secretbox.Open(dataKey, envelope.Ciphertext, additionalData)
+}
+
+// Rewrap moves the data key of envelope from the from KEK to the to KEK,
+// the ciphertext is left untouched.
+func Rewrap(from, to KeyProvider, envelope *Envelope) (*Envelope, error) {
+	if from == nil || to == nil {
+		return nil, ErrNoProvider
+	}
+	if envelope.KeyID != from.KeyID() {
+		return nil, fmt.Errorf("%w: %s", ErrKeyMismatch, envelope.KeyID)
+	}
+
+	dataKey, err := from.UnwrapKey(envelope.WrappedKey)
+	if err != nil {
+		return nil, err
+	}
+	defer secretbox.Zero(dataKey)
+
+	wrappedKey, err := to.WrapKey(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	return &Envelope{KeyID: to.KeyID(), WrappedKey: wrappedKey, Ciphertext: envelope.Ciphertext}, nil
+}
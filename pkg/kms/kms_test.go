@@ -0,0 +1,136 @@
+package kms
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/miekg/pkcs11"
+)
+
+func TestSealOpenRewrap(t *testing.T) {
+	oldKEK, err := newLocalProvider(ProviderFile, strings.Repeat("11", 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("WALLET_TEST_KEK", strings.Repeat("22", 32))
+	newKEK, err := NewEnvProvider("WALLET_TEST_KEK")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	envelope, err := Seal(oldKEK, []byte("seed"), []byte("wallet-1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if envelope.KeyID != oldKEK.KeyID() {
+		t.Errorf("key id = %s", envelope.KeyID)
+	}
+
+	if _, err = Open(newKEK, envelope, []byte("wallet-1")); !errors.Is(err, ErrKeyMismatch) {
+		t.Errorf("expected ErrKeyMismatch, got %v", err)
+	}
+
+	rewrapped, err := Rewrap(oldKEK, newKEK, envelope)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(rewrapped.Ciphertext, envelope.Ciphertext) {
+		t.Error("rewrap must not touch the ciphertext")
+	}
+	plaintext, err := Open(newKEK, rewrapped, []byte("wallet-1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(plaintext) != "seed" {
+		t.Errorf("plaintext = %q", plaintext)
+	}
+}
+
+// TestPkcs11Provider runs against SoftHSM, point SOFTHSM2_MODULE at
+// libsofthsm2.so and SOFTHSM2_TOKEN / SOFTHSM2_PIN at an initialized token.
+func TestPkcs11Provider(t *testing.T) {
+	module := os.Getenv("SOFTHSM2_MODULE")
+	if module == "" {
+		t.Skip("SOFTHSM2_MODULE is not set")
+	}
+	token, pin := os.Getenv("SOFTHSM2_TOKEN"), os.Getenv("SOFTHSM2_PIN")
+
+	ctx, err := openPkcs11Module(module)
+	if err != nil {
+		t.Fatal(err)
+	}
+	slot, err := findSlot(ctx, token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	session, err := ctx.OpenSession(slot, pkcs11.CKF_SERIAL_SESSION|pkcs11.CKF_RW_SESSION)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ctx.CloseSession(session)
+	if err = ctx.Login(session, pkcs11.CKU_USER, pin); err != nil && !isPkcs11Error(err, pkcs11.CKR_USER_ALREADY_LOGGED_IN) {
+		t.Fatal(err)
+	}
+	generate := func(label string, id byte) pkcs11.ObjectHandle {
+		key, err := ctx.GenerateKey(session, []*pkcs11.Mechanism{pkcs11.NewMechanism(pkcs11.CKM_AES_KEY_GEN, nil)}, []*pkcs11.Attribute{
+			pkcs11.NewAttribute(pkcs11.CKA_TOKEN, true),
+			pkcs11.NewAttribute(pkcs11.CKA_LABEL, label),
+			pkcs11.NewAttribute(pkcs11.CKA_ID, []byte{id}),
+			pkcs11.NewAttribute(pkcs11.CKA_VALUE_LEN, 32),
+			pkcs11.NewAttribute(pkcs11.CKA_ENCRYPT, true),
+			pkcs11.NewAttribute(pkcs11.CKA_DECRYPT, true),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { ctx.DestroyObject(session, key) })
+		return key
+	}
+
+	oldKey := generate("wallet-kms-test-old", 1)
+	generate("wallet-kms-test-new", 2)
+	oldProvider, err := NewPkcs11Provider(module, token, pin, "wallet-kms-test-old")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A second provider on the same module, as in a pkcs11 to pkcs11 rewrap.
+	newProvider, err := NewPkcs11Provider(module, token, pin, "wallet-kms-test-new")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	envelope, err := Seal(oldProvider, []byte("seed"), []byte("wallet-1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rewrapped, err := Rewrap(oldProvider, newProvider, envelope)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plaintext, err := Open(newProvider, rewrapped, []byte("wallet-1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(plaintext) != "seed" {
+		t.Errorf("plaintext = %q", plaintext)
+	}
+
+	// A key replaced under the same label gets a new key id.
+	if err = ctx.DestroyObject(session, oldKey); err != nil {
+		t.Fatal(err)
+	}
+	generate("wallet-kms-test-old", 3)
+	replaced, err := NewPkcs11Provider(module, token, pin, "wallet-kms-test-old")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if replaced.KeyID() == oldProvider.KeyID() {
+		t.Errorf("replaced key kept key id %s", replaced.KeyID())
+	}
+	if _, err = Open(replaced, envelope, []byte("wallet-1")); !errors.Is(err, ErrKeyMismatch) {
+		t.Errorf("expected ErrKeyMismatch, got %v", err)
+	}
+}
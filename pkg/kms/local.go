@@ -0,0 +1,61 @@
+package kms
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+	"wallet/pkg/tools/secretbox"
+)
+
+// localProvider keeps the KEK in process memory and wraps data keys with
+// AES-256-GCM, it backs both the key file and the environment providers.
+type localProvider struct {
+	keyID string
+	kek   []byte
+}
+
+// NewFileProvider reads a hex encoded 32 byte KEK from path.
+func NewFileProvider(path string) (KeyProvider, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read kek file: %w", err)
+	}
+	return newLocalProvider(ProviderFile, string(data))
+}
+
+// NewEnvProvider reads a hex encoded 32 byte KEK from the environment variable name.
+func NewEnvProvider(name string) (KeyProvider, error) {
+	value, ok := os.LookupEnv(name)
+	if !ok {
+		return nil, fmt.Errorf("kek environment variable %q is not set", name)
+	}
+	return newLocalProvider(ProviderEnv, value)
+}
+
+func newLocalProvider(kind, hexKey string) (*localProvider, error) {
+	kek, err := hex.DecodeString(strings.TrimSpace(hexKey))
+	if err != nil {
+		return nil, fmt.Errorf("decode %s kek: %w", kind, err)
+	}
+	if len(kek) != secretbox.KeySize {
+		return nil, secretbox.ErrInvalidKey
+	}
+	// The id is a fingerprint of the key, so a rotated key always gets a
+	// new id even when it is read from the same file or variable.
+	fingerprint := sha256.Sum256(kek)
+	return &localProvider{keyID: kind + ":" + hex.EncodeToString(fingerprint[:8]), kek: kek}, nil
+}
+
+func (p *localProvider) KeyID() string {
+	return p.keyID
+}
+
+func (p *localProvider) WrapKey(dataKey []byte) ([]byte, error) {
+	return secretbox.Seal(p.kek, dataKey, []byte(p.keyID))
+}
+
+func (p *localProvider) UnwrapKey(wrappedKey []byte) ([]byte, error) {
+	return secretbox.Open(p.kek, wrappedKey, []byte(p.keyID))
+}
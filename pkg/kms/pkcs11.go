@@ -0,0 +1,197 @@
+package kms
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/miekg/pkcs11"
+)
+
+const (
+	gcmNonceSize = 12
+	gcmTagBits   = 128
+)
+
+// pkcs11Provider wraps data keys with an AES key that lives on a PKCS#11
+// token (an HSM, or SoftHSM in development) using CKM_AES_GCM.
+type pkcs11Provider struct {
+	mu      sync.Mutex
+	ctx     *pkcs11.Ctx
+	session pkcs11.SessionHandle
+	key     pkcs11.ObjectHandle
+	keyID   string
+}
+
+// pkcs11Modules holds one initialized context per module path. A module may
+// only be initialized once per process, every provider on it shares the
+// context, e.g. the old and new KEK of a rotation.
+var pkcs11Modules = struct {
+	sync.Mutex
+	ctx map[string]*pkcs11.Ctx
+}{ctx: make(map[string]*pkcs11.Ctx)}
+
+// NewPkcs11Provider loads module, logs into the token labelled tokenLabel and
+// looks up the secret key labelled keyLabel.
+func NewPkcs11Provider(module, tokenLabel, pin, keyLabel string) (KeyProvider, error) {
+	ctx, err := openPkcs11Module(module)
+	if err != nil {
+		return nil, err
+	}
+
+	slot, err := findSlot(ctx, tokenLabel)
+	if err != nil {
+		return nil, err
+	}
+	session, err := ctx.OpenSession(slot, pkcs11.CKF_SERIAL_SESSION)
+	if err != nil {
+		return nil, fmt.Errorf("open pkcs11 session: %w", err)
+	}
+	// The login state is shared by all sessions of a token.
+	if err = ctx.Login(session, pkcs11.CKU_USER, pin); err != nil && !isPkcs11Error(err, pkcs11.CKR_USER_ALREADY_LOGGED_IN) {
+		ctx.CloseSession(session)
+		return nil, fmt.Errorf("pkcs11 login: %w", err)
+	}
+
+	key, err := findSecretKey(ctx, session, keyLabel)
+	if err != nil {
+		ctx.CloseSession(session)
+		return nil, err
+	}
+	fingerprint, err := keyFingerprint(ctx, session, key)
+	if err != nil {
+		ctx.CloseSession(session)
+		return nil, err
+	}
+	return &pkcs11Provider{ctx: ctx, session: session, key: key, keyID: ProviderPkcs11 + ":" + keyLabel + ":" + fingerprint}, nil
+}
+
+// openPkcs11Module returns the shared context of module, initializing it on
+// first use. A module some other code in the process already initialized
+// is used as is.
+func openPkcs11Module(module string) (*pkcs11.Ctx, error) {
+	pkcs11Modules.Lock()
+	defer pkcs11Modules.Unlock()
+
+	if ctx, ok := pkcs11Modules.ctx[module]; ok {
+		return ctx, nil
+	}
+	ctx := pkcs11.New(module)
+	if ctx == nil {
+		return nil, fmt.Errorf("load pkcs11 module %q failed", module)
+	}
+	if err := ctx.Initialize(); err != nil && !isPkcs11Error(err, pkcs11.CKR_CRYPTOKI_ALREADY_INITIALIZED) {
+		ctx.Destroy()
+		return nil, fmt.Errorf("initialize pkcs11 module: %w", err)
+	}
+	pkcs11Modules.ctx[module] = ctx
+	return ctx, nil
+}
+
+func isPkcs11Error(err error, code uint) bool {
+	var pkcs11Err pkcs11.Error
+	return errors.As(err, &pkcs11Err) && uint(pkcs11Err) == code
+}
+
+func findSlot(ctx *pkcs11.Ctx, tokenLabel string) (uint, error) {
+	slots, err := ctx.GetSlotList(true)
+	if err != nil {
+		return 0, fmt.Errorf("list pkcs11 slots: %w", err)
+	}
+	for _, slot := range slots {
+		info, err := ctx.GetTokenInfo(slot)
+		if err != nil {
+			continue
+		}
+		if info.Label == tokenLabel {
+			return slot, nil
+		}
+	}
+	return 0, fmt.Errorf("pkcs11 token %q not found", tokenLabel)
+}
+
+func findSecretKey(ctx *pkcs11.Ctx, session pkcs11.SessionHandle, keyLabel string) (pkcs11.ObjectHandle, error) {
+	template := []*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_CLASS, pkcs11.CKO_SECRET_KEY),
+		pkcs11.NewAttribute(pkcs11.CKA_KEY_TYPE, pkcs11.CKK_AES),
+		pkcs11.NewAttribute(pkcs11.CKA_LABEL, keyLabel),
+	}
+	if err := ctx.FindObjectsInit(session, template); err != nil {
+		return 0, fmt.Errorf("find pkcs11 key: %w", err)
+	}
+	objects, _, err := ctx.FindObjects(session, 1)
+	if finalErr := ctx.FindObjectsFinal(session); err == nil {
+		err = finalErr
+	}
+	if err != nil {
+		return 0, fmt.Errorf("find pkcs11 key: %w", err)
+	}
+	if len(objects) == 0 {
+		return 0, fmt.Errorf("pkcs11 key %q not found", keyLabel)
+	}
+	return objects[0], nil
+}
+
+// keyFingerprint tells apart keys that share a label, e.g. a rotated key
+// generated under the old label. It is the CKA_ID of the key, or its
+// CKA_CHECK_VALUE when the key has no id.
+func keyFingerprint(ctx *pkcs11.Ctx, session pkcs11.SessionHandle, key pkcs11.ObjectHandle) (string, error) {
+	for _, attribute := range []uint{pkcs11.CKA_ID, pkcs11.CKA_CHECK_VALUE} {
+		values, err := ctx.GetAttributeValue(session, key, []*pkcs11.Attribute{pkcs11.NewAttribute(attribute, nil)})
+		if err == nil && len(values) == 1 && len(values[0].Value) > 0 {
+			return hex.EncodeToString(values[0].Value), nil
+		}
+	}
+	return "", errors.New("pkcs11 key has neither CKA_ID nor CKA_CHECK_VALUE, set a CKA_ID on it")
+}
+
+// KeyID is pkcs11:<label>:<fingerprint>. It is also the GCM additional data
+// of every wrapped key, a data key never unwraps under another key.
+func (p *pkcs11Provider) KeyID() string {
+	return p.keyID
+}
+
+// WrapKey returns nonce || ciphertext || tag.
+func (p *pkcs11Provider) WrapKey(dataKey []byte) ([]byte, error) {
+	nonce := make([]byte, gcmNonceSize)
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	params := pkcs11.NewGCMParams(nonce, []byte(p.KeyID()), gcmTagBits)
+	defer params.Free()
+	mechanism := []*pkcs11.Mechanism{pkcs11.NewMechanism(pkcs11.CKM_AES_GCM, params)}
+	if err := p.ctx.EncryptInit(p.session, mechanism, p.key); err != nil {
+		return nil, fmt.Errorf("pkcs11 encrypt init: %w", err)
+	}
+	ciphertext, err := p.ctx.Encrypt(p.session, dataKey)
+	if err != nil {
+		return nil, fmt.Errorf("pkcs11 encrypt: %w", err)
+	}
+	return append(nonce, ciphertext...), nil
+}
+
+func (p *pkcs11Provider) UnwrapKey(wrappedKey []byte) ([]byte, error) {
+	if len(wrappedKey) <= gcmNonceSize {
+		return nil, ErrInvalidWrappedKey
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	params := pkcs11.NewGCMParams(wrappedKey[:gcmNonceSize], []byte(p.KeyID()), gcmTagBits)
+	defer params.Free()
+	mechanism := []*pkcs11.Mechanism{pkcs11.NewMechanism(pkcs11.CKM_AES_GCM, params)}
+	if err := p.ctx.DecryptInit(p.session, mechanism, p.key); err != nil {
+		return nil, fmt.Errorf("pkcs11 decrypt init: %w", err)
+	}
+	dataKey, err := p.ctx.Decrypt(p.session, wrappedKey[gcmNonceSize:])
+	if err != nil {
+		return nil, fmt.Errorf("pkcs11 decrypt: %w", err)
+	}
+	return dataKey, nil
+}
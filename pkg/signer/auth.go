@@ -0,0 +1,167 @@
+package signer
+
+import (
+	"bufio"
+	"context"
+	"crypto/subtle"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"wallet/pkg/common/config"
+	"wallet/pkg/zlogger"
+)
+
+// The signing host signs any hash for any caller that reaches it, so both
+// ends refuse to talk without TLS and a client credential unless told they
+// run in development.
+
+const (
+	authorizationHeader = "authorization"
+	bearerPrefix        = "Bearer "
+)
+
+var ErrNoRemoteCredentials = errors.New("remote signer needs a ca certificate and a token or client certificate")
+
+// tokenCredentials sends the client token with every call, never in plain
+// text.
+type tokenCredentials string
+
+func (t tokenCredentials) GetRequestMetadata(context.Context, ...string) (map[string]string, error) {
+	return map[string]string{authorizationHeader: bearerPrefix + string(t)}, nil
+}
+
+func (tokenCredentials) RequireTransportSecurity() bool {
+	return true
+}
+
+// DialOptions builds the transport and call credentials towards the
+// signing host described by cfg.
+func DialOptions(cfg config.SignerConfig) ([]grpc.DialOption, error) {
+	if cfg.Insecure {
+		zlogger.Warnf("remote signer connection is not authenticated, development only")
+		return []grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}, nil
+	}
+	hasClientCert := cfg.RemoteClientCert != "" || cfg.RemoteClientKey != ""
+	if cfg.RemoteCACert == "" || (cfg.RemoteToken == "" && !hasClientCert) {
+		return nil, ErrNoRemoteCredentials
+	}
+
+	pem, err := os.ReadFile(cfg.RemoteCACert)
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig := &tls.Config{RootCAs: x509.NewCertPool(), MinVersion: tls.VersionTLS12}
+	if !tlsConfig.RootCAs.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificate found in %s", cfg.RemoteCACert)
+	}
+	if hasClientCert {
+		cert, err := tls.LoadX509KeyPair(cfg.RemoteClientCert, cfg.RemoteClientKey)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	opts := []grpc.DialOption{grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig))}
+	if cfg.RemoteToken != "" {
+		opts = append(opts, grpc.WithPerRPCCredentials(tokenCredentials(cfg.RemoteToken)))
+	}
+	return opts, nil
+}
+
+// ServerCredentials serves TLS with certFile and keyFile. A non-empty
+// clientCAFile additionally requires every client to present a certificate
+// issued by it.
+func ServerCredentials(certFile, keyFile, clientCAFile string) (credentials.TransportCredentials, error) {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig := &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12}
+	if clientCAFile != "" {
+		pem, err := os.ReadFile(clientCAFile)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.ClientCAs = x509.NewCertPool()
+		if !tlsConfig.ClientCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificate found in %s", clientCAFile)
+		}
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return credentials.NewTLS(tlsConfig), nil
+}
+
+// LoadTokens reads one "<client> <token>" pair per line, blank lines and
+// lines starting with # are skipped.
+func LoadTokens(file string) (map[string]string, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	tokens := make(map[string]string)
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		fields := strings.Fields(text)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("%s:%d: want \"<client> <token>\"", file, line)
+		}
+		tokens[fields[0]] = fields[1]
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("%s holds no tokens", file)
+	}
+	return tokens, nil
+}
+
+// TokenAuth rejects calls whose bearer token is not one of tokens, keyed by
+// client name.
+func TokenAuth(tokens map[string]string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		client, ok := authenticate(ctx, tokens)
+		if !ok {
+			zlogger.Warnf("[TokenAuth] rejected unauthenticated call to %s", info.FullMethod)
+			return nil, status.Error(codes.Unauthenticated, "invalid or missing token")
+		}
+		zlogger.Infof("[TokenAuth] %s calls %s", client, info.FullMethod)
+		return handler(ctx, req)
+	}
+}
+
+func authenticate(ctx context.Context, tokens map[string]string) (string, bool) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get(authorizationHeader)
+	if len(values) != 1 || !strings.HasPrefix(values[0], bearerPrefix) {
+		return "", false
+	}
+	token := []byte(strings.TrimPrefix(values[0], bearerPrefix))
+
+	// Compare against every token so the time taken does not tell which
+	// one, if any, shares a prefix with the guess.
+	var client string
+	for name, want := range tokens {
+		if subtle.ConstantTimeCompare(token, []byte(want)) == 1 {
+			client = name
+		}
+	}
+	return client, client != ""
+}
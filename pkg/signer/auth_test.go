@@ -0,0 +1,121 @@
+package signer
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"wallet/pkg/common/config"
+	"wallet/pkg/zlogger"
+)
+
+// writeTestCert writes a self signed certificate for "localhost" and its key,
+// the certificate doubles as the CA clients verify against.
+func writeTestCert(t *testing.T) (certFile, keyFile string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "localhost"},
+		DNSNames:              []string{"localhost"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	certFile, keyFile = filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	if err = os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err = os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return certFile, keyFile
+}
+
+func TestRemoteTokenAuth(t *testing.T) {
+	zlogger.SetEmptyLogger()
+	privateKey, err := crypto.HexToECDSA(testKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certFile, keyFile := writeTestCert(t)
+	creds, err := ServerCredentials(certFile, keyFile, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lis := bufconn.Listen(1 << 20)
+	server := grpc.NewServer(grpc.Creds(creds), grpc.UnaryInterceptor(TokenAuth(map[string]string{"api": "s3cret"})))
+	NewRemoteServer(map[string]Signer{"hot": NewLocalSigner(privateKey)}).Register(server)
+	go server.Serve(lis)
+	t.Cleanup(server.Stop)
+
+	dial := func(token string) *grpc.ClientConn {
+		opts, err := DialOptions(config.SignerConfig{RemoteCACert: certFile, RemoteToken: token})
+		if err != nil {
+			t.Fatal(err)
+		}
+		opts = append(opts, grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return lis.Dial() }))
+		conn, err := grpc.Dial("localhost", opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { conn.Close() })
+		return conn
+	}
+
+	if _, err = NewRemoteSigner(context.Background(), dial("s3cret"), "hot"); err != nil {
+		t.Fatalf("valid token rejected: %v", err)
+	}
+	_, err = NewRemoteSigner(context.Background(), dial("guess"), "hot")
+	if status.Code(err) != codes.Unauthenticated {
+		t.Errorf("expected Unauthenticated for a wrong token, got %v", err)
+	}
+}
+
+func TestDialOptionsRequireCredentials(t *testing.T) {
+	zlogger.SetEmptyLogger()
+	certFile, _ := writeTestCert(t)
+	for _, cfg := range []config.SignerConfig{
+		{},
+		{RemoteToken: "s3cret"},
+		{RemoteCACert: certFile},
+	} {
+		if _, err := DialOptions(cfg); err != ErrNoRemoteCredentials {
+			t.Errorf("%+v: expected ErrNoRemoteCredentials, got %v", cfg, err)
+		}
+	}
+	if _, err := DialOptions(config.SignerConfig{Insecure: true}); err != nil {
+		t.Errorf("insecure: %v", err)
+	}
+}
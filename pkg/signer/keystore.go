@@ -0,0 +1,58 @@
+package signer
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/fbsobreira/gotron-sdk/pkg/proto/core"
+	"wallet/pkg/hdwallet/keystore"
+	"wallet/pkg/tools/secretbox"
+)
+
+// KeystoreSigner holds a keystore v3 file and decrypts the key only for the
+// duration of each signature.
+type KeystoreSigner struct {
+	keyJSON   []byte
+	password  string
+	publicKey *ecdsa.PublicKey
+}
+
+func NewKeystoreSigner(keyJSON []byte, password string) (*KeystoreSigner, error) {
+	privateKey, err := keystore.Decrypt(keyJSON, password)
+	if err != nil {
+		return nil, err
+	}
+	defer secretbox.ZeroKey(privateKey)
+
+	publicKey := privateKey.PublicKey
+	return &KeystoreSigner{keyJSON: keyJSON, password: password, publicKey: &publicKey}, nil
+}
+
+func (s *KeystoreSigner) PublicKey() *ecdsa.PublicKey {
+	return s.publicKey
+}
+
+func (s *KeystoreSigner) SignHash(_ context.Context, hash []byte) ([]byte, error) {
+	privateKey, err := keystore.Decrypt(s.keyJSON, s.password)
+	if err != nil {
+		return nil, err
+	}
+	defer secretbox.ZeroKey(privateKey)
+	return crypto.Sign(hash, privateKey)
+}
+
+func (s *KeystoreSigner) SignTronTransaction(ctx context.Context, tx *core.Transaction) (*core.Transaction, error) {
+	return signTronTransaction(ctx, s, tx)
+}
+
+func (s *KeystoreSigner) SignEthTransaction(ctx context.Context, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	return signEthTransaction(ctx, s, tx, chainID)
+}
+
+func (s *KeystoreSigner) Close() error {
+	s.password = ""
+	return nil
+}
@@ -0,0 +1,43 @@
+package signer
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/fbsobreira/gotron-sdk/pkg/proto/core"
+	"wallet/pkg/tools/secretbox"
+)
+
+// LocalSigner keeps the private key in process memory until Close.
+type LocalSigner struct {
+	privateKey *ecdsa.PrivateKey
+	publicKey  *ecdsa.PublicKey
+}
+
+func NewLocalSigner(privateKey *ecdsa.PrivateKey) *LocalSigner {
+	return &LocalSigner{privateKey: privateKey, publicKey: &privateKey.PublicKey}
+}
+
+func (s *LocalSigner) PublicKey() *ecdsa.PublicKey {
+	return s.publicKey
+}
+
+func (s *LocalSigner) SignHash(_ context.Context, hash []byte) ([]byte, error) {
+	return crypto.Sign(hash, s.privateKey)
+}
+
+func (s *LocalSigner) SignTronTransaction(ctx context.Context, tx *core.Transaction) (*core.Transaction, error) {
+	return signTronTransaction(ctx, s, tx)
+}
+
+func (s *LocalSigner) SignEthTransaction(ctx context.Context, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	return signEthTransaction(ctx, s, tx, chainID)
+}
+
+func (s *LocalSigner) Close() error {
+	secretbox.ZeroKey(s.privateKey)
+	return nil
+}
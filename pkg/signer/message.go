@@ -0,0 +1,54 @@
+package signer
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// tronMessagePrefix is the TIP-191 prefix of TronWeb signMessageV2.
+const tronMessagePrefix = "\x19TRON Signed Message:\n"
+
+var ErrInvalidSignatureFormat = errors.New("signature must be 65 bytes with v of 0, 1, 27 or 28")
+
+// EthMessageHash is the EIP-191 personal_sign hash of message.
+func EthMessageHash(message []byte) []byte {
+	return accounts.TextHash(message)
+}
+
+// TronMessageHash is the TIP-191 signMessageV2 hash of message.
+func TronMessageHash(message []byte) []byte {
+	return crypto.Keccak256([]byte(fmt.Sprintf("%s%d", tronMessagePrefix, len(message))), message)
+}
+
+// SignMessageHash signs a prefixed message hash, V is returned as 27 or 28
+// like personal_sign and signMessageV2 do.
+func SignMessageHash(ctx context.Context, s Signer, hash []byte) ([]byte, error) {
+	signature, err := s.SignHash(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+	signature[crypto.RecoveryIDOffset] += 27
+	return signature, nil
+}
+
+// RecoverMessageSigner returns the public key that signed a prefixed message
+// hash, V may be 0/1 or 27/28.
+func RecoverMessageSigner(hash, signature []byte) (*ecdsa.PublicKey, error) {
+	if len(signature) != crypto.SignatureLength {
+		return nil, ErrInvalidSignatureFormat
+	}
+	sig := make([]byte, crypto.SignatureLength)
+	copy(sig, signature)
+	if sig[crypto.RecoveryIDOffset] >= 27 {
+		sig[crypto.RecoveryIDOffset] -= 27
+	}
+	if sig[crypto.RecoveryIDOffset] > 1 {
+		return nil, ErrInvalidSignatureFormat
+	}
+	return crypto.SigToPub(hash, sig)
+}
@@ -0,0 +1,220 @@
+package signer
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/fbsobreira/gotron-sdk/pkg/proto/core"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/encoding"
+	"google.golang.org/grpc/status"
+	"wallet/pkg/common/config"
+	"wallet/pkg/zlogger"
+)
+
+// The remote signer speaks gRPC with a JSON codec, so both ends are plain Go
+// structs and no generated protobuf code is needed.
+const (
+	remoteServiceName = "wallet.signer.Signer"
+	methodPublicKey   = "/" + remoteServiceName + "/PublicKey"
+	methodSignHash    = "/" + remoteServiceName + "/SignHash"
+	jsonCodecName     = "json"
+)
+
+type PublicKeyRequest struct {
+	KeyID string `json:"key_id"`
+}
+
+type PublicKeyResponse struct {
+	// PublicKey is the uncompressed 65 byte secp256k1 public key.
+	PublicKey []byte `json:"public_key"`
+}
+
+type SignHashRequest struct {
+	KeyID string `json:"key_id"`
+	Hash  []byte `json:"hash"`
+}
+
+type SignHashResponse struct {
+	Signature []byte `json:"signature"`
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+func (jsonCodec) Name() string {
+	return jsonCodecName
+}
+
+func init() {
+	encoding.RegisterCodec(jsonCodec{})
+}
+
+// RemoteConn is the connection to the signing host, nil when no remote
+// signer is configured.
+var RemoteConn *grpc.ClientConn
+
+func Init() {
+	if config.Config.Signer.RemoteAddress == "" {
+		return
+	}
+
+	opts, err := DialOptions(config.Config.Signer)
+	if err != nil {
+		zlogger.Errorf("load remote signer credentials error %v", err)
+		panic(err)
+	}
+	RemoteConn, err = grpc.Dial(config.Config.Signer.RemoteAddress, opts...)
+	if err != nil {
+		zlogger.Errorf("connect remote signer error %v", err)
+		panic(err)
+	}
+	zlogger.Infof("Connected remote signer %s", config.Config.Signer.RemoteAddress)
+}
+
+// RemoteSigner forwards hashes to a signing host, the key never leaves it.
+type RemoteSigner struct {
+	conn      *grpc.ClientConn
+	keyID     string
+	publicKey *ecdsa.PublicKey
+}
+
+// NewRemoteSigner looks up the public key of keyID on the signing host.
+func NewRemoteSigner(ctx context.Context, conn *grpc.ClientConn, keyID string) (*RemoteSigner, error) {
+	var resp PublicKeyResponse
+	err := conn.Invoke(ctx, methodPublicKey, &PublicKeyRequest{KeyID: keyID}, &resp, grpc.CallContentSubtype(jsonCodecName))
+	if err != nil {
+		return nil, err
+	}
+	publicKey, err := crypto.UnmarshalPubkey(resp.PublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("remote signer returned an invalid public key: %w", err)
+	}
+	return &RemoteSigner{conn: conn, keyID: keyID, publicKey: publicKey}, nil
+}
+
+func (s *RemoteSigner) PublicKey() *ecdsa.PublicKey {
+	return s.publicKey
+}
+
+func (s *RemoteSigner) SignHash(ctx context.Context, hash []byte) ([]byte, error) {
+	var resp SignHashResponse
+	err := s.conn.Invoke(ctx, methodSignHash, &SignHashRequest{KeyID: s.keyID, Hash: hash}, &resp, grpc.CallContentSubtype(jsonCodecName))
+	if err != nil {
+		return nil, err
+	}
+
+	// Never trust the signing host blindly, the signature must recover to
+	// the key we resolved up front.
+	recovered, err := crypto.SigToPub(hash, resp.Signature)
+	if err != nil || !recovered.Equal(s.publicKey) {
+		return nil, ErrInvalidSignature
+	}
+	return resp.Signature, nil
+}
+
+func (s *RemoteSigner) SignTronTransaction(ctx context.Context, tx *core.Transaction) (*core.Transaction, error) {
+	return signTronTransaction(ctx, s, tx)
+}
+
+func (s *RemoteSigner) SignEthTransaction(ctx context.Context, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	return signEthTransaction(ctx, s, tx, chainID)
+}
+
+// Close is a no-op, the connection is shared and owned by the caller.
+func (s *RemoteSigner) Close() error {
+	return nil
+}
+
+// RemoteServer is the signing host side, it serves the keys it was given.
+type RemoteServer struct {
+	signers map[string]Signer
+}
+
+func NewRemoteServer(signers map[string]Signer) *RemoteServer {
+	return &RemoteServer{signers: signers}
+}
+
+// Register adds the signer service to server.
+func (s *RemoteServer) Register(server *grpc.Server) {
+	server.RegisterService(&grpc.ServiceDesc{
+		ServiceName: remoteServiceName,
+		HandlerType: (*interface{})(nil),
+		Methods: []grpc.MethodDesc{
+			{MethodName: "PublicKey", Handler: s.handlePublicKey},
+			{MethodName: "SignHash", Handler: s.handleSignHash},
+		},
+	}, s)
+}
+
+func (s *RemoteServer) signer(keyID string) (Signer, error) {
+	signer, ok := s.signers[keyID]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "key %q not found", keyID)
+	}
+	return signer, nil
+}
+
+func (s *RemoteServer) publicKey(_ context.Context, req *PublicKeyRequest) (*PublicKeyResponse, error) {
+	signer, err := s.signer(req.KeyID)
+	if err != nil {
+		return nil, err
+	}
+	return &PublicKeyResponse{PublicKey: crypto.FromECDSAPub(signer.PublicKey())}, nil
+}
+
+func (s *RemoteServer) signHash(ctx context.Context, req *SignHashRequest) (*SignHashResponse, error) {
+	if len(req.Hash) != 32 {
+		return nil, status.Error(codes.InvalidArgument, "hash must be 32 bytes")
+	}
+	signer, err := s.signer(req.KeyID)
+	if err != nil {
+		return nil, err
+	}
+	signature, err := signer.SignHash(ctx, req.Hash)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &SignHashResponse{Signature: signature}, nil
+}
+
+func (s *RemoteServer) handlePublicKey(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	req := new(PublicKeyRequest)
+	if err := dec(req); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return s.publicKey(ctx, req)
+	}
+	info := &grpc.UnaryServerInfo{Server: srv, FullMethod: methodPublicKey}
+	return interceptor(ctx, req, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return s.publicKey(ctx, req.(*PublicKeyRequest))
+	})
+}
+
+func (s *RemoteServer) handleSignHash(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	req := new(SignHashRequest)
+	if err := dec(req); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return s.signHash(ctx, req)
+	}
+	info := &grpc.UnaryServerInfo{Server: srv, FullMethod: methodSignHash}
+	return interceptor(ctx, req, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return s.signHash(ctx, req.(*SignHashRequest))
+	})
+}
@@ -0,0 +1,41 @@
+package signer
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/fbsobreira/gotron-sdk/pkg/proto/core"
+	"wallet/pkg/hdwallet/tron/sign"
+)
+
+var ErrInvalidSignature = errors.New("signer returned an invalid signature")
+
+// Signer signs Tron and Ethereum transactions with a single secp256k1 key
+// without exposing it, the key may live in process, in an encrypted keystore
+// or on a remote signing host.
+type Signer interface {
+	PublicKey() *ecdsa.PublicKey
+	// SignHash returns the 65 byte [R || S || V] signature of hash, V is 0 or 1.
+	SignHash(ctx context.Context, hash []byte) ([]byte, error)
+	// SignTronTransaction appends the signature of the sha256 raw data hash.
+	SignTronTransaction(ctx context.Context, tx *core.Transaction) (*core.Transaction, error)
+	SignEthTransaction(ctx context.Context, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error)
+	// Close releases key material held by the signer.
+	Close() error
+}
+
+func signTronTransaction(ctx context.Context, s sign.HashSigner, tx *core.Transaction) (*core.Transaction, error) {
+	return sign.TransactionSign(ctx, tx, s)
+}
+
+func signEthTransaction(ctx context.Context, s sign.HashSigner, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	txSigner := types.LatestSignerForChainID(chainID)
+	signature, err := s.SignHash(ctx, txSigner.Hash(tx).Bytes())
+	if err != nil {
+		return nil, err
+	}
+	return tx.WithSignature(txSigner, signature)
+}
@@ -0,0 +1,279 @@
+package signer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"math/big"
+	"net"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+	"github.com/fbsobreira/gotron-sdk/pkg/proto/core"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+	"wallet/pkg/hdwallet/keystore"
+	"wallet/pkg/hdwallet/tron/sign"
+)
+
+const testKey = "4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318"
+
+// startRemote serves signers on an in-memory listener, standing in for the
+// isolated signing host.
+func startRemote(t *testing.T, signers map[string]Signer) *grpc.ClientConn {
+	lis := bufconn.Listen(1 << 20)
+	server := grpc.NewServer()
+	NewRemoteServer(signers).Register(server)
+	go server.Serve(lis)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return lis.Dial() }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func testSigners(t *testing.T) map[string]Signer {
+	ctx := context.Background()
+	privateKey, err := crypto.HexToECDSA(testKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyJSON, err := keystore.Encrypt(privateKey, "password", keystore.KdfPbkdf2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keystoreSigner, err := NewKeystoreSigner(keyJSON, "password")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	localKey, err := crypto.HexToECDSA(testKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	remoteKey, err := crypto.HexToECDSA(testKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn := startRemote(t, map[string]Signer{"hot": NewLocalSigner(remoteKey)})
+	remoteSigner, err := NewRemoteSigner(ctx, conn, "hot")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return map[string]Signer{
+		"local":    NewLocalSigner(localKey),
+		"keystore": keystoreSigner,
+		"remote":   remoteSigner,
+	}
+}
+
+func TestSignEthTransaction(t *testing.T) {
+	ctx := context.Background()
+	want := crypto.PubkeyToAddress(crypto.ToECDSAUnsafe(common.FromHex(testKey)).PublicKey)
+	to := common.HexToAddress("0x3535353535353535353535353535353535353535")
+	chainID := big.NewInt(11155111)
+
+	for name, s := range testSigners(t) {
+		tx := types.NewTx(&types.LegacyTx{Nonce: 9, To: &to, Value: big.NewInt(1), Gas: 21000, GasPrice: big.NewInt(1)})
+		signed, err := s.SignEthTransaction(ctx, tx, chainID)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		from, err := types.Sender(types.LatestSignerForChainID(chainID), signed)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if from != want {
+			t.Errorf("%s: sender = %s, want %s", name, from.Hex(), want.Hex())
+		}
+		if err = s.Close(); err != nil {
+			t.Errorf("%s: close: %v", name, err)
+		}
+	}
+}
+
+func TestSignTronTransaction(t *testing.T) {
+	ctx := context.Background()
+	want := crypto.FromECDSAPub(&crypto.ToECDSAUnsafe(common.FromHex(testKey)).PublicKey)
+
+	for name, s := range testSigners(t) {
+		tx := &core.Transaction{RawData: &core.TransactionRaw{RefBlockBytes: []byte{1, 2}, Expiration: 1700000000000}}
+		signed, err := s.SignTronTransaction(ctx, tx)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if len(signed.Signature) != 1 {
+			t.Fatalf("%s: %d signatures", name, len(signed.Signature))
+		}
+		hash, err := sign.GetTransactionHash(signed)
+		if err != nil {
+			t.Fatal(err)
+		}
+		publicKey, err := crypto.Ecrecover(hash, signed.Signature[0])
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if !bytes.Equal(publicKey, want) {
+			t.Errorf("%s: signature recovers to another key", name)
+		}
+	}
+}
+
+func TestRemoteSignerUnknownKey(t *testing.T) {
+	conn := startRemote(t, map[string]Signer{})
+	if _, err := NewRemoteSigner(context.Background(), conn, "missing"); err == nil {
+		t.Error("expected unknown key error")
+	}
+}
+
+// lyingSigner returns signatures of another key, the remote client must
+// reject them.
+type lyingSigner struct {
+	*LocalSigner
+	other *LocalSigner
+}
+
+func (s lyingSigner) SignHash(ctx context.Context, hash []byte) ([]byte, error) {
+	return s.other.SignHash(ctx, hash)
+}
+
+func TestRemoteSignerVerifiesSignature(t *testing.T) {
+	privateKey, err := crypto.HexToECDSA(testKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherKey, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn := startRemote(t, map[string]Signer{"hot": lyingSigner{NewLocalSigner(privateKey), NewLocalSigner(otherKey)}})
+
+	s, err := NewRemoteSigner(context.Background(), conn, "hot")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = s.SignHash(context.Background(), crypto.Keccak256([]byte("hello"))); err != ErrInvalidSignature {
+		t.Errorf("expected ErrInvalidSignature, got %v", err)
+	}
+}
+
+func TestSignMessage(t *testing.T) {
+	ctx := context.Background()
+	privateKey, err := crypto.HexToECDSA(testKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewLocalSigner(privateKey)
+	message := []byte("hello")
+
+	if got := common.Bytes2Hex(EthMessageHash(message)); got != "50b2c43fd39106bafbba0da34fc430e1f91e3c96ea2acee2bc34119f92b37750" {
+		t.Errorf("eth message hash = %s", got)
+	}
+	tronHash := crypto.Keccak256([]byte("\x19TRON Signed Message:\n5hello"))
+	if !bytes.Equal(TronMessageHash(message), tronHash) {
+		t.Error("tron message hash mismatch")
+	}
+
+	for _, hash := range [][]byte{EthMessageHash(message), TronMessageHash(message)} {
+		signature, err := SignMessageHash(ctx, s, hash)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if v := signature[64]; v != 27 && v != 28 {
+			t.Errorf("v = %d", v)
+		}
+		publicKey, err := RecoverMessageSigner(hash, signature)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !publicKey.Equal(s.PublicKey()) {
+			t.Error("recovered another key")
+		}
+	}
+
+	if _, err = RecoverMessageSigner(EthMessageHash(message), make([]byte, 64)); err != ErrInvalidSignatureFormat {
+		t.Errorf("expected ErrInvalidSignatureFormat, got %v", err)
+	}
+}
+
+// mailTypedData is the example of the EIP-712 specification.
+const mailTypedData = `{
+	"types": {
+		"EIP712Domain": [
+			{"name": "name", "type": "string"},
+			{"name": "version", "type": "string"},
+			{"name": "chainId", "type": "uint256"},
+			{"name": "verifyingContract", "type": "address"}
+		],
+		"Person": [
+			{"name": "name", "type": "string"},
+			{"name": "wallet", "type": "address"}
+		],
+		"Mail": [
+			{"name": "from", "type": "Person"},
+			{"name": "to", "type": "Person"},
+			{"name": "contents", "type": "string"}
+		]
+	},
+	"primaryType": "Mail",
+	"domain": {
+		"name": "Ether Mail",
+		"version": "1",
+		"chainId": 1,
+		"verifyingContract": "0xCcCCccccCCCCcCCCCCCcCcCccCcCCCcCcccccccC"
+	},
+	"message": {
+		"from": {"name": "Cow", "wallet": "0xCD2a3d9F938E13CD947Ec05AbC7FE734Df8DD826"},
+		"to": {"name": "Bob", "wallet": "0xbBbBBBBbbBBBbbbBbbBbbbbBBbBbbbbBbBbbBBbB"},
+		"contents": "Hello, Bob!"
+	}
+}`
+
+func TestHashTypedData(t *testing.T) {
+	var data apitypes.TypedData
+	if err := json.Unmarshal([]byte(mailTypedData), &data); err != nil {
+		t.Fatal(err)
+	}
+
+	hash, err := HashTypedData(data, big.NewInt(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := hexutil.Encode(hash.DomainSeparator); got != "0xf2cee375fa42b42143804025fc449deafd50cc031ca257e0b194a650a912090f" {
+		t.Errorf("domain separator = %s", got)
+	}
+	if got := hexutil.Encode(hash.MessageHash); got != "0xc52c0ee5d84264471806290a3f2c4cecfc5490626bf912d01f240d7a274b371e" {
+		t.Errorf("message hash = %s", got)
+	}
+	if got := hexutil.Encode(hash.Digest); got != "0xbe609aee343fb3c4b28e1df9e632fca64fcfaede20f02e86244efddf30957bd2" {
+		t.Errorf("digest = %s", got)
+	}
+
+	signature, err := SignMessageHash(context.Background(), NewLocalSigner(crypto.ToECDSAUnsafe(crypto.Keccak256([]byte("cow")))), hash.Digest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "0x4355c47d63924e8a72e509b65029052eb6c299d53a04e167c5775fd466751c9d07299936d304c153f6443dfa05f40ff007d72911b6f72307f996231605b915621c"
+	if got := hexutil.Encode(signature); got != want {
+		t.Errorf("signature = %s", got)
+	}
+
+	if _, err = HashTypedData(data, big.NewInt(11155111)); err != ErrChainIDMismatch {
+		t.Errorf("expected ErrChainIDMismatch, got %v", err)
+	}
+	data.Domain.ChainId = nil
+	if _, err = HashTypedData(data, big.NewInt(1)); err != ErrMissingChainID {
+		t.Errorf("expected ErrMissingChainID, got %v", err)
+	}
+}
@@ -0,0 +1,49 @@
+package signer
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+)
+
+var (
+	ErrMissingChainID  = errors.New("typed data domain has no chainId")
+	ErrChainIDMismatch = errors.New("typed data chainId does not match the network")
+)
+
+// TypedDataHash holds the EIP-712 digest of a typed data document and the
+// two struct hashes it is built from.
+type TypedDataHash struct {
+	DomainSeparator []byte
+	MessageHash     []byte
+	// Digest is keccak256("\x19\x01" || DomainSeparator || MessageHash),
+	// the hash that gets signed.
+	Digest []byte
+}
+
+// HashTypedData computes the EIP-712 digest of data, its domain must name
+// chainID so the signature cannot be replayed on another chain.
+func HashTypedData(data apitypes.TypedData, chainID *big.Int) (*TypedDataHash, error) {
+	if data.Domain.ChainId == nil {
+		return nil, ErrMissingChainID
+	}
+	if (*big.Int)(data.Domain.ChainId).Cmp(chainID) != 0 {
+		return nil, ErrChainIDMismatch
+	}
+
+	domainSeparator, err := data.HashStruct("EIP712Domain", data.Domain.Map())
+	if err != nil {
+		return nil, err
+	}
+	messageHash, err := data.HashStruct(data.PrimaryType, data.Message)
+	if err != nil {
+		return nil, err
+	}
+	return &TypedDataHash{
+		DomainSeparator: domainSeparator,
+		MessageHash:     messageHash,
+		Digest:          crypto.Keccak256([]byte("\x19\x01"), domainSeparator, messageHash),
+	}, nil
+}
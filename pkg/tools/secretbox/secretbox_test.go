@@ -0,0 +1,45 @@
+package secretbox
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"testing"
+)
+
+func TestSealOpen(t *testing.T) {
+	key := bytes.Repeat([]byte{7}, KeySize)
+	sealed, err := Seal(key, []byte("seed"), []byte("wallet-1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	plaintext, err := Open(key, sealed, []byte("wallet-1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(plaintext) != "seed" {
+		t.Errorf("plaintext = %q", plaintext)
+	}
+
+	if _, err = Open(key, sealed, []byte("wallet-2")); err == nil {
+		t.Error("expected additional data mismatch to fail")
+	}
+	if _, err = Open(key[:16], sealed, []byte("wallet-1")); err != ErrInvalidKey {
+		t.Errorf("expected ErrInvalidKey, got %v", err)
+	}
+}
+
+func TestZeroKey(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ZeroKey(key)
+	for _, word := range key.D.Bits() {
+		if word != 0 {
+			t.Fatal("private scalar not overwritten")
+		}
+	}
+}